@@ -0,0 +1,352 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUpgradeCacheV1(t *testing.T) {
+	data := upgradeCacheV1(map[string]map[string]CacheEntry{
+		"es": {"save file": {Value: "guardar archivo"}, "open": {Value: "abrir"}},
+	})
+	for key, e := range data["es"] {
+		if e.Source != "" || e.From != "auto" || e.Engine != "legacy" {
+			t.Errorf("%s: %+v, want legacy entry without source", key, e)
+		}
+	}
+	for _, c := range []struct {
+		text, value, source string
+	}{
+		{"Save File", "guardar archivo", "save file"},
+		{" Open ", "abrir", "open"},
+	} {
+		key := legacyCacheKey(c.text)
+		e, ok := data["es"][key]
+		if !ok || e.Value != c.value || cacheSourceText(key, e) != c.source {
+			t.Errorf("%q: got %+v (%v), want %q from %q", c.text, e, ok, c.value, c.source)
+		}
+	}
+}
+
+func TestPlanEviction(t *testing.T) {
+	now := time.Now()
+	data := map[string]map[string]CacheEntry{"es": {}}
+	for i := 0; i < 10; i++ {
+		data["es"][fmt.Sprint(i)] = CacheEntry{Value: "x", LastUsed: now.Add(-time.Duration(i) * 24 * time.Hour)}
+	}
+	data["es"]["h"] = CacheEntry{Value: "x", LastUsed: now.Add(-100 * 24 * time.Hour), Human: true}
+	ttl, err := parseAge("4.5d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size, err := parseByteSize("2MiB"); err != nil || size != 2<<20 {
+		t.Errorf("parseByteSize(2MiB) = %d, %v", size, err)
+	}
+	for _, c := range []struct {
+		name        string
+		policy      cachePolicy
+		n           int
+		first, last string // só para o LRU, que remove em ordem
+	}{
+		{"ttl", cachePolicy{TTL: ttl}, 5, "", ""},
+		{"lru", cachePolicy{MaxEntries: 3}, 7, "9", "3"},
+	} {
+		plan := planEviction(data, c.policy, now)
+		if len(plan) != c.n || c.first != "" && (plan[0].key != c.first || plan[len(plan)-1].key != c.last) {
+			t.Errorf("%s: %d entries %v, want %d from %s to %s", c.name, len(plan), plan, c.n, c.first, c.last)
+		}
+	}
+}
+
+func TestParseMergeStrategy(t *testing.T) {
+	now := time.Now()
+	machine := CacheEntry{Value: "m", LastUsed: now, Engine: "google"}
+	human := CacheEntry{Value: "h", LastUsed: now.Add(-time.Hour), Engine: "google", Human: true}
+	deepl := CacheEntry{Value: "d", LastUsed: now.Add(-time.Hour), Engine: "deepl"}
+	for _, c := range []struct {
+		name    string
+		e, cur  CacheEntry
+		replace bool
+	}{
+		{"newest", human, machine, false},
+		{"human", human, machine, true},
+		{"engine:deepl", deepl, machine, true},
+		{"engine:deepl", machine, deepl, false},
+	} {
+		prefer, err := parseMergeStrategy(c.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := prefer(c.e, c.cur); got != c.replace {
+			t.Errorf("%s: %s over %s = %v, want %v", c.name, c.e.Value, c.cur.Value, got, c.replace)
+		}
+	}
+	if _, err := parseMergeStrategy("engine:"); err == nil {
+		t.Error("engine: without a name accepted")
+	}
+}
+
+func TestResolveMerge(t *testing.T) {
+	now := time.Now()
+	machine := CacheEntry{Value: "m", LastUsed: now, Engine: "google", Source: "Olá", From: "auto"}
+	human := CacheEntry{Value: "h", LastUsed: now.Add(-time.Hour), Engine: humanEngine, Source: "Olá", From: "auto", Human: true}
+	deepl := CacheEntry{Value: "d", LastUsed: now.Add(-time.Hour), Engine: "deepl", Source: "Olá", From: "auto"}
+	older := machine
+	older.Value, older.LastUsed = "velha", now.Add(-2*time.Hour)
+	a := map[string]map[string]CacheEntry{"en": {"google|auto|Olá": machine, "human|auto|Olá": human}}
+	b := map[string]map[string]CacheEntry{"en": {"deepl|auto|Olá": deepl, "google|auto|Olá": older}}
+
+	// Motores diferentes para o mesmo texto são mantidos; a estratégia só
+	// escolhe o valor relatado. Na mesma chave fica só a preferida.
+	for _, c := range []struct {
+		strategy, winner string
+	}{
+		{"human", "human|auto|Olá"},
+		{"engine:deepl", "deepl|auto|Olá"},
+		{"newest", "google|auto|Olá"},
+	} {
+		prefer, err := parseMergeStrategy(c.strategy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		merged, groups, winners := resolveMerge([]string{"a", "b"}, []map[string]map[string]CacheEntry{a, b}, prefer)
+		if len(merged["en"]) != 3 || merged["en"]["google|auto|Olá"].Value != "m" {
+			t.Errorf("%s: merged %v, want all three keys with the newest google entry", c.strategy, merged["en"])
+		}
+		if len(groups["en"]) != 1 {
+			t.Fatalf("%s: %d groups, want 1", c.strategy, len(groups["en"]))
+		}
+		if got := groups["en"]["auto|Olá"][winners["en"]["auto|Olá"]].key; got != c.winner {
+			t.Errorf("%s: reported %s, want %s", c.strategy, got, c.winner)
+		}
+	}
+}
+
+func TestRemoteCache(t *testing.T) {
+	store := make(map[string]CacheEntry)
+	var muStore sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		muStore.Lock()
+		defer muStore.Unlock()
+		lang := r.URL.Query().Get("l")
+		if r.Method == http.MethodPut {
+			var in map[string]CacheEntry
+			json.NewDecoder(r.Body).Decode(&in)
+			for k, e := range in {
+				store[lang+"/"+k] = e
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		out := make(map[string]CacheEntry)
+		for _, k := range r.URL.Query()["k"] {
+			if e, ok := store[lang+"/"+k]; ok {
+				out[k] = e
+			}
+		}
+		json.NewEncoder(w).Encode(out)
+	}))
+	r := &remoteCache{base: srv.URL, token: "t", client: &http.Client{Timeout: 2 * time.Second}}
+	r.put("es", map[string]CacheEntry{"google|auto|Olá": {Value: "Hola"}})
+	if got := r.get("es", []string{"human|auto|Olá", "google|auto|Olá"}); len(got) != 1 || got["google|auto|Olá"].Value != "Hola" {
+		t.Fatalf("get = %v, want only google|auto|Olá", got)
+	}
+	srv.Close()
+	r.warn.Do(func() {}) // sem o aviso no meio da saída do teste
+	if got := r.get("es", []string{"google|auto|Olá"}); got != nil || !r.offline.Load() {
+		t.Fatalf("server down: get = %v, offline = %v", got, r.offline.Load())
+	}
+}
+
+// useTestCache troca o cache do processo por um banco vazio no diretório
+// temporário do teste.
+func useTestCache(t *testing.T) string {
+	t.Helper()
+	saved, savedFile := storage.db, cacheFile
+	cacheFile = filepath.Join(t.TempDir(), "cache.db")
+	db, err := openCacheDB(cacheFile, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.db, storage.touched = db, nil
+	t.Cleanup(func() {
+		db.Close()
+		storage.db, storage.touched, cacheFile = saved, nil, savedFile
+	})
+	return cacheFile
+}
+
+func TestCacheStorage(t *testing.T) {
+	useTestCache(t)
+	old := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	storage.batch(func() {
+		storage.put("es", "google|en|Open", CacheEntry{Value: "Abrir", LastUsed: old, Source: "Open", From: "en", Engine: "google"})
+		storage.put("es", "human|en|Open", CacheEntry{Value: "Abrir (revisado)", LastUsed: old, Source: "Open", From: "en", Engine: humanEngine, Human: true})
+		storage.put("es", "google|en|Close", CacheEntry{Value: "Cerrar", LastUsed: old, Source: "Close", From: "en", Engine: "google"})
+	})
+	if e, ok := storage.get("es", "google|en|Open"); !ok || e.Value != "Abrir" || !e.LastUsed.Equal(old) {
+		t.Errorf("get = %+v, %v", e, ok)
+	}
+	if key, e, ok := storage.findText("es", "en", "Open"); !ok || key != "human|en|Open" || !e.Human {
+		t.Errorf("findText = %s %+v %v, want the human entry", key, e, ok)
+	}
+	storage.del("es", "google|en|Close")
+	if _, ok := storage.get("es", "google|en|Close"); ok || storage.count() != 2 {
+		t.Errorf("after delete: %d entries", storage.count())
+	}
+	storage.touch("es", "google|en|Open", time.Now())
+	saveCache()
+	if e, _ := storage.get("es", "google|en|Open"); !e.LastUsed.After(old) {
+		t.Errorf("touch not saved: %v", e.LastUsed)
+	}
+}
+
+func TestCacheServerToken(t *testing.T) {
+	srv := httptest.NewServer(cacheServerHandler("segredo"))
+	defer srv.Close()
+	for auth, want := range map[string]int{
+		"":                http.StatusUnauthorized,
+		"Bearer segred":   http.StatusUnauthorized,
+		"Bearer segredo!": http.StatusUnauthorized,
+		"Bearer segredo":  http.StatusOK,
+	} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/v1/health", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("Authorization %q: status %d, want %d", auth, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestCacheRecovery(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cache.db")
+	data := map[string]map[string]CacheEntry{"es": {}}
+	for i := 0; i < 2000; i++ {
+		key := cacheKey("google", "pt", fmt.Sprintf("Texto número %d com algum conteúdo", i))
+		data["es"][key] = CacheEntry{Value: fmt.Sprintf("Texto número %d", i), LastUsed: time.Now(), Engine: "google"}
+	}
+	if err := writeCacheSnapshot(path, data); err != nil {
+		t.Fatal(err)
+	}
+	read, err := readCacheFile(path)
+	if err != nil || countEntries(read) != 2000 {
+		t.Fatalf("readCacheFile: %d entries, %v", countEntries(read), err)
+	}
+	if _, problems, err := checkCacheDB(path); err != nil || len(problems) > 0 {
+		t.Fatalf("healthy cache: %v, %v", problems, err)
+	}
+
+	// Uma página do meio sobrescrita com lixo.
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := len(raw) / 4096 / 2 * 4096
+	copy(raw[page:page+4096], bytes.Repeat([]byte{0xa5}, 4096))
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, problems, err := checkCacheDB(path); err == nil && len(problems) == 0 {
+		t.Error("damaged cache passed the integrity check")
+	}
+
+	legacy := filepath.Join(dir, "cache.json")
+	os.WriteFile(legacy, []byte(`{"es":{"ola":{"v":"hola"}}}`), 0644)
+	if read, err := readCacheFile(legacy); err != nil || read["es"][legacyCacheKey("ola")].Value != "hola" {
+		t.Errorf("cache.json: %v, %v", read, err)
+	}
+	salvaged, ok := salvageLegacyCache([]byte(`{"es":{"ola":{"v":"hola"},"mundo":{"v":"mun`))
+	if ok || salvaged["es"]["ola"].Value != "hola" {
+		t.Errorf("salvage: ok = %v, entries %v", ok, salvaged)
+	}
+}
+
+func TestTMXRoundTrip(t *testing.T) {
+	in := tmxDoc{Version: "1.4", Units: []tmxTU{{
+		Props:    []tmxProp{{"x-chili-engine", "deepl"}},
+		Variants: []tmxTUV{{Lang: "en", Seg: "Save & quit"}, {Lang: tmxLang("pt_BR"), Seg: "Salvar e sair"}},
+	}}}
+	data, err := xml.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte(`xml:lang="pt-BR"`)) {
+		t.Errorf("missing xml:lang: %s", data)
+	}
+	var out tmxDoc
+	if err := xml.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Units) != 1 || out.Units[0].prop("x-chili-engine") != "deepl" ||
+		cacheLang(out.Units[0].Variants[1].Lang) != "pt_BR" || out.Units[0].Variants[0].Seg != "Save & quit" {
+		t.Errorf("round trip: %+v", out.Units)
+	}
+}
+
+func TestCacheLang(t *testing.T) {
+	for tag, want := range map[string]string{
+		"zh-Hans":    "zh_CN",
+		"zh-hant-HK": "zh_TW",
+		"pt-br":      "pt_BR",
+		"sr-latn-rs": "sr_Latn_RS",
+		"de":         "de",
+	} {
+		if got := cacheLang(tag); got != want {
+			t.Errorf("cacheLang(%q) = %q, want %q", tag, got, want)
+		}
+	}
+}
+
+func TestImportTMX(t *testing.T) {
+	doc := tmxDoc{Header: tmxHeader{SrcLang: "en"}, Units: []tmxTU{
+		{Variants: []tmxTUV{{Lang: "en", Seg: "Open"}, {Lang: "de", Seg: "Öffnen"}}},
+		{Variants: []tmxTUV{{Lang: "en", Seg: "Close"}, {Seg: "Schließen"}}},
+		{Props: []tmxProp{{"x-chili-engine", "deepl"}, {"x-chili-from", "en"}},
+			Variants: []tmxTUV{{Lang: "en", Seg: "Save"}, {Lang: "de", Seg: "Speichern"}}},
+	}}
+	for _, c := range []struct {
+		human  bool
+		engine string
+	}{
+		{false, tmxEngine},
+		{true, humanEngine},
+	} {
+		useTestCache(t)
+		added, skipped, noLang := importTMX(doc, &cacheFilter{}, false, c.human)
+		got := storage.all()
+		if added != 2 || skipped != 0 || noLang != 1 {
+			t.Errorf("human=%v: added %d, skipped %d, without lang %d; want 2, 0, 1", c.human, added, skipped, noLang)
+		}
+		if _, ok := got[""]; ok {
+			t.Errorf("human=%v: unit without xml:lang stored", c.human)
+		}
+		e, ok := got["de"][cacheKey(c.engine, "auto", "Open")]
+		if !ok || e.Value != "Öffnen" || e.Human != c.human {
+			t.Errorf("human=%v: foreign unit %+v (%v), want %s entry", c.human, e, ok, c.engine)
+		}
+		if e := got["de"][cacheKey("deepl", "en", "Save")]; e.Value != "Speichern" || e.Human {
+			t.Errorf("human=%v: chili unit %+v, want deepl entry", c.human, e)
+		}
+	}
+}
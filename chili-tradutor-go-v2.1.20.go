@@ -13,28 +13,97 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/binary"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/rand"
 	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
+	"text/template"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/pflag"
+	_ "modernc.org/sqlite"
 )
 
 // --- ESTRUTURAS E VARIÁVEIS GLOBAIS ---
 
+// CacheEntry é uma tradução guardada. Desde o esquema 2 a chave combina
+// motor, idioma de origem e texto exato (ver cacheKey); Source, From e
+// Engine repetem essas partes para consultas e exportação.
 type CacheEntry struct {
 	Value    string    `json:"v"`
 	LastUsed time.Time `json:"t"`
+	Source   string    `json:"s,omitempty"`
+	From     string    `json:"f,omitempty"`
+	Engine   string    `json:"e,omitempty"`
+	Human    bool      `json:"h,omitempty"`
+}
+
+// EngineConfig guarda as opções de um motor no arquivo de configuração.
+// Flags de linha de comando e variáveis de ambiente têm precedência.
+type EngineConfig struct {
+	Endpoint  string            `json:"endpoint,omitempty"`
+	APIKey    string            `json:"api_key,omitempty"`
+	Formality string            `json:"formality,omitempty"`
+	Glossary  map[string]string `json:"glossary,omitempty"`
+	Model     string            `json:"model,omitempty"`
+	Prompt    string            `json:"prompt,omitempty"`
+	Timeout   string            `json:"timeout,omitempty"`
+	// Limites de vazão: requisições por segundo e caracteres por minuto.
+	RateLimit      float64 `json:"rate_limit,omitempty"`
+	CharsPerMinute int     `json:"chars_per_minute,omitempty"`
+}
+
+// CommandConfig descreve um motor baseado em programa externo (Apertium,
+// argos-translate, scripts próprios). Args aceita {src}, {tgt} e {text};
+// Input é "stdin" (padrão) ou "argv"; Output é "text" (padrão),
+// "json:<campo.aninhado>" ou "regex:<expressão com um grupo>".
+type CommandConfig struct {
+	Command   string            `json:"command"`
+	Args      []string          `json:"args,omitempty"`
+	Input     string            `json:"input,omitempty"`
+	Output    string            `json:"output,omitempty"`
+	Timeout   string            `json:"timeout,omitempty"`
+	Languages map[string]string `json:"languages,omitempty"`
+}
+
+type Config struct {
+	Engines  map[string]EngineConfig  `json:"engines,omitempty"`
+	Commands map[string]CommandConfig `json:"commands,omitempty"`
+	Routes   map[string]string        `json:"routes,omitempty"`
+	Cache    CacheConfig              `json:"cache,omitempty"`
+}
+
+// CacheConfig define a política de expurgo do cache (veja cachePolicy) e o
+// servidor de cache compartilhado (veja remoteCache).
+type CacheConfig struct {
+	TTL        string `json:"ttl,omitempty"`
+	MaxEntries int    `json:"max_entries,omitempty"`
+	MaxSize    string `json:"max_size,omitempty"`
+	URL        string `json:"url,omitempty"`
+	Token      string `json:"token,omitempty"`
 }
 
 const (
@@ -57,18 +126,45 @@ var (
 	inputFiles     []string
 	currentFile    string
 	engine         string
+	endpoint       string
+	apiKey         string
+	formality      string
+	glossaries     []string
+	routes         []string
+	model          string
+	promptFile     string
+	configFile     string
+	config         Config
 	sourceLang     string
 	jobs           int
+	batchSize      int
+	batchChars     int
+	callTimeout    time.Duration
+	rateLimit      float64
+	charsPerMinute int
+	retries        int
+	backoffBase    time.Duration
+	backoffMax     time.Duration
+	breakerLimit   int
+	breakerCooling time.Duration
 	forceFlag      bool
+	forceHumanFlag bool
+	cacheFallback  bool
+	markFuzzyFlag  bool
+	moEndianness   string
 	quietFlag      bool
 	verboseFlag    bool
 	versionFlag    bool
 	cleanCacheFlag bool
 	selfFlag       bool
 	selfTestFlag   bool
+	resumeFlag     bool
+	stateFile      string
+	appCtx         = context.Background()
 	languages      []string
 	targetLangs    []string
 	cacheFile      string
+	legacyCache    string
 	cacheData      map[string]map[string]CacheEntry
 	mu             sync.Mutex
 	muConsole      sync.Mutex
@@ -97,6 +193,20 @@ func execCommand(name string, args ...string) *exec.Cmd {
 	return cmd
 }
 
+// execCommandContext roda o programa num grupo de processos próprio; ao
+// cancelar o contexto (timeout ou Ctrl-C) o grupo inteiro é morto, inclusive
+// filhos como o gawk/curl do translate-shell que seguram o stdout aberto.
+func execCommandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), "LC_ALL=C", "LANG=C")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = 2 * time.Second
+	return cmd
+}
+
 // --- INICIALIZAÇÃO E MAIN ---
 
 func init() {
@@ -106,49 +216,104 @@ func init() {
 	}
 	cacheDir := filepath.Join(home, ".cache", _APP_)
 	os.MkdirAll(cacheDir, 0755)
-	cacheFile = filepath.Join(cacheDir, "cache.json")
+	cacheFile = filepath.Join(cacheDir, "cache.db")
+	legacyCache = filepath.Join(cacheDir, "cache.json")
+	stateFile = filepath.Join(cacheDir, "resume.json")
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		configDir = filepath.Join(home, ".config")
+	}
+	configFile = filepath.Join(configDir, _APP_, "config.json")
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "cache" {
+		code := runCacheCommand(os.Args[2:])
+		closeCache()
+		os.Exit(code)
+	}
+
+	parseFlags()
 	checkDependencies()
 	isOnline = checkInternet()
-	parseFlags()
 
 	if versionFlag {
 		showVersion()
 		os.Exit(0)
 	}
 
+	setupSignals()
 	loadCache()
+	defer closeCache()
 	defer saveCache()
 
 	if selfTestFlag {
 		runFullSelfTest()
-		os.Exit(0)
+		exitSavingCache(0)
 	}
 
 	if cleanCacheFlag {
 		doCleanCache()
-		os.Exit(0)
+		exitSavingCache(0)
 	}
 
 	allFiles := append(inputFiles, pflag.Args()...)
 	if len(allFiles) == 0 {
 		usage()
-		os.Exit(1)
+		exitSavingCache(1)
 	}
 
+	if resumeFlag {
+		loadRunState()
+	}
 	startGlobal := time.Now()
 	for _, file := range allFiles {
+		if appCtx.Err() != nil {
+			break
+		}
 		processSingleFile(file)
 	}
 
+	if appCtx.Err() != nil {
+		fmt.Printf("\n\n%s %s\n", yellow(T("[AVISO]")), white(T("Execução interrompida. Cache salvo; continue com --resume.")))
+		exitSavingCache(130)
+	}
+	os.Remove(stateFile)
+
 	if len(allFiles) > 1 {
 		fmt.Printf("\n%s %s\n", green("✔"), white(T("Todos os arquivos foram processados!")))
 		showFinalSummary(startGlobal)
+	} else {
+		showEngineSummary()
 	}
 }
 
+// setupSignals faz SIGINT/SIGTERM cancelarem appCtx: chamadas em andamento
+// são abortadas e main salva o cache e o progresso antes de sair. Um segundo
+// sinal encerra imediatamente.
+func setupSignals() {
+	ctx, cancel := context.WithCancel(context.Background())
+	appCtx = ctx
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		cancel()
+		muConsole.Lock()
+		fmt.Printf("\n%s %s\n", yellow(T("[AVISO]")), white(T("Interrompendo: aguardando as traduções em andamento para salvar o cache...")))
+		muConsole.Unlock()
+		<-sigs
+		os.Exit(130)
+	}()
+}
+
+func exitSavingCache(code int) {
+	saveCache()
+	closeCache()
+	os.Exit(code)
+}
+
 func processSingleFile(path string) {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		fmt.Printf("%s %s '%s'\n", red(T("ERRO:")), white(T("Arquivo não encontrado:")), yellow(path))
@@ -157,6 +322,7 @@ func processSingleFile(path string) {
 
 	currentFile = path
 	langsDone = 0
+	resetFailureReport()
 	ext, langName, desc := detectFileType(path)
 	baseName := filepath.Base(path)
 	setupEnvironment(ext, baseName, langName)
@@ -217,58 +383,56 @@ func runFullSelfTest() {
 		fmt.Println(red("FALHA"))
 	}
 
+	for _, name := range sortedKeys(engineInstances) {
+		fmt.Printf("    %s %-35s ", blue("→"), fmt.Sprintf(T("Motor de tradução (%s)"), name))
+		ctx, cancel := context.WithTimeout(appCtx, engineTimeout(engineInstances[name]))
+		err := engineInstances[name].Health(ctx)
+		cancel()
+		if err == nil {
+			fmt.Println(green("OK"))
+		} else {
+			fmt.Println(red("FALHA"), white(err.Error()))
+		}
+	}
+
 	fmt.Printf("\n%s %s\n\n", green("✔"), white(T("SISTEMA 100% VALIDADO EM TODOS OS NÍVEIS.")))
 }
 
-func stampPotHeader(path string, lang string) {
-	langValue := "none"
+// poHeader monta o cabeçalho que o chili grava nos .pot e .po gerados.
+func poHeader(lang string) *poEntry {
+	langValue, plural := "none", "nplurals=INTEGER; plural=EXPRESSION;"
 	if lang != "" {
-		langValue = lang
-	}
-	header := fmt.Sprintf(
-		"# Chili Tradutor Go - %s\n"+
-			"# Copyright (C) 2019-2026 Vilmar Catafesta <vcatafesta@gmail.com>\n"+
-			"# This file is distributed under the same license as the %s package.\n"+
-			"msgid \"\"\n"+
-			"msgstr \"\"\n"+
-			"\"Project-Id-Version: %s %s\\n\"\n"+
-			"\"POT-Creation-Date: %s\\n\"\n"+
-			"\"PO-Revision-Date: %s\\n\"\n"+
-			"\"Last-Translator: Vilmar Catafesta <vcatafesta@gmail.com>\\n\"\n"+
-			"\"Language-Team: Portuguese <https://github.com/chililinux/chili-tradutor-go>\\n\"\n"+
-			"\"MIME-Version: 1.0\\n\"\n"+
-			"\"Content-Type: text/plain; charset=UTF-8\\n\"\n"+
-			"\"Content-Transfer-Encoding: 8bit\\n\"\n"+
-			"\"Language: %s\\n\"\n"+
-			"\"Plural-Forms: nplurals=2; plural=(n > 1);\\n\"\n\n",
-		_VERSION_, _APP_, _APP_, _VERSION_, 
-		time.Now().Format("2006-01-02 15:04-0700"), 
-		time.Now().Format("2006-01-02 15:04-0700"), 
-		langValue,
-	)
-
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return
+		langValue, plural = lang, pluralFormsFor(lang).header()
 	}
-
-	lines := strings.Split(string(content), "\n")
-	var actualContent []string
-	found := false
-	for _, line := range lines {
-		if found || strings.HasPrefix(line, "#:") {
-			found = true
-			actualContent = append(actualContent, line)
-		}
+	now := time.Now().Format("2006-01-02 15:04-0700")
+	return &poEntry{
+		Comments: []string{
+			"# Chili Tradutor Go - " + _VERSION_,
+			"# Copyright (C) 2019-2026 Vilmar Catafesta <vcatafesta@gmail.com>",
+			"# This file is distributed under the same license as the " + _APP_ + " package.",
+		},
+		Str: []string{
+			"Project-Id-Version: " + _APP_ + " " + _VERSION_ + "\n" +
+				"POT-Creation-Date: " + now + "\n" +
+				"PO-Revision-Date: " + now + "\n" +
+				"Last-Translator: Vilmar Catafesta <vcatafesta@gmail.com>\n" +
+				"Language-Team: Portuguese <https://github.com/chililinux/chili-tradutor-go>\n" +
+				"MIME-Version: 1.0\n" +
+				"Content-Type: text/plain; charset=UTF-8\n" +
+				"Content-Transfer-Encoding: 8bit\n" +
+				"Language: " + langValue + "\n" +
+				"Plural-Forms: " + plural + "\n",
+		},
 	}
-	if found {
-		final := header + strings.Join(actualContent, "\n")
-		os.WriteFile(path, []byte(final), 0644)
-	} else {
-		re := regexp.MustCompile(`(?s)^msgid "".*?msgstr "".*?\n\n`)
-		newContent := re.ReplaceAll(content, []byte(""))
-		os.WriteFile(path, append([]byte(header), newContent...), 0644)
+}
+
+func stampPotHeader(path string, lang string) {
+	po, err := readPOFile(path)
+	if err != nil {
+		return
 	}
+	po.Header = poHeader(lang)
+	writePOFile(path, po)
 }
 
 func runTranslationLoop(ext, baseName string) {
@@ -286,7 +450,16 @@ func runTranslationLoop(ext, baseName string) {
 		wg.Add(1)
 		go func(l string) {
 			defer wg.Done()
+			if resumeFlag && isLangDone(currentFile, l) {
+				atomic.AddInt32(&langsDone, 1)
+				updateProgress(l, 1, 1, "SKIP")
+				return
+			}
 			sem <- struct{}{}
+			if appCtx.Err() != nil {
+				<-sem
+				return
+			}
 			
 			if isMan {
 				translateManPage(currentFile, l)
@@ -303,9 +476,14 @@ func runTranslationLoop(ext, baseName string) {
 				default:
 					prepareMsginit(targetBase, l)
 					translateFile(targetBase, l)
-					writeMsgfmtToMo(targetBase, l)
+					writeMoFile(targetBase, l)
 				}
 			}
+			if appCtx.Err() != nil {
+				<-sem
+				return
+			}
+			markLangDone(currentFile, l)
 			
 			atomic.AddInt32(&langsDone, 1)
 			muConsole.Lock()
@@ -320,610 +498,5496 @@ func runTranslationLoop(ext, baseName string) {
 }
 
 func callUniversalTranslator(text, lang string) string {
+	res, _ := translateOne(text, lang)
+	return res
+}
+
+// segmentOrigin diz de onde veio a tradução de um segmento: o motor (ou
+// "human" e "legacy", para entradas do cache) e se ela saiu do cache. Engine
+// vazio quer dizer que nenhum motor traduziu e o texto voltou como estava.
+type segmentOrigin struct {
+	Engine string
+	Cached bool
+}
+
+func cacheOrigin(entry CacheEntry) segmentOrigin {
+	switch {
+	case entry.Human:
+		return segmentOrigin{Engine: humanEngine, Cached: true}
+	case entry.Engine == "":
+		return segmentOrigin{Engine: "legacy", Cached: true}
+	}
+	return segmentOrigin{Engine: entry.Engine, Cached: true}
+}
+
+func translateOne(text, lang string) (string, segmentOrigin) {
+	pending := make(map[string]CacheEntry)
+	res, origin := translateSegment(text, lang, pending)
+	pushRemoteEntries(lang, pending)
+	return res, origin
+}
+
+// translateSegment traduz um segmento e acrescenta a tradução nova a
+// pending, para o chamador enviá-la ao --cache-url junto com as demais.
+func translateSegment(text, lang string, pending map[string]CacheEntry) (string, segmentOrigin) {
 	text = strings.TrimSpace(text)
 	if text == "" {
-		return ""
+		return "", segmentOrigin{}
 	}
-	normID := strings.ToLower(text)
-	mu.Lock()
-	if cacheData == nil {
-		cacheData = make(map[string]map[string]CacheEntry)
-	}
-	if _, ok := cacheData[lang]; !ok {
-		cacheData[lang] = make(map[string]CacheEntry)
-	}
-	if entry, exists := cacheData[lang][normID]; exists && !forceFlag {
-		entry.LastUsed = time.Now()
-		cacheData[lang][normID] = entry
-		cacheHits++
-		mu.Unlock()
-		return entry.Value
+	chain := enginesFor(lang)
+	if entry, ok := cacheLookup(chain, lang, text); ok {
+		return entry.Value, cacheOrigin(entry)
 	}
-	mu.Unlock()
-	if !isOnline {
-		return text
+
+	if appCtx.Err() != nil {
+		return text, segmentOrigin{}
 	}
 
-	transLang := strings.ReplaceAll(lang, "_", "-")
 	protectedText, placeholders := protectVariables(text)
-	var res string
-	var err error
-	for i := 0; i < 3; i++ {
-		cmd := execCommand("trans", "-e", engine, "-s", sourceLang, "-no-init", "-no-autocorrect", "-b", ":"+transLang)
-		cmd.Stdin = strings.NewReader(protectedText)
-		out, errCmd := cmd.Output()
-		if errCmd == nil {
-			res = restoreVariables(strings.TrimSpace(string(out)), placeholders)
-			err = nil
-			break
+	tried := false
+	for _, e := range chain {
+		if !isOnline && !isLocalEngine(e) {
+			continue
 		}
-		err = errCmd
-		time.Sleep(time.Duration(i+1) * time.Second)
+		tried = true
+		var out string
+		err := callEngine(e, lang, len(protectedText), func(ctx context.Context) error {
+			var errEng error
+			out, errEng = e.Translate(ctx, protectedText, sourceLang, lang)
+			return errEng
+		})
+		if err != nil {
+			continue
+		}
+		res := restoreVariables(strings.TrimSpace(out), placeholders)
+		cacheStore(e, lang, text, res, pending)
+		return res, segmentOrigin{Engine: engineCacheKey(e)}
 	}
-	if err != nil {
+	if tried && appCtx.Err() == nil {
 		atomic.AddInt32(&failedCalls, 1)
-		return text
+		recordFailure(lang, failUntranslated)
 	}
-	netCalls++
-	mu.Lock()
-	cacheData[lang][normID] = CacheEntry{Value: res, LastUsed: time.Now()}
-	mu.Unlock()
-	return res
+	return text, segmentOrigin{}
 }
 
-func prepareGettext(inputPath, baseName, lang string) {
-	cleanName := strings.TrimSuffix(baseName, filepath.Ext(baseName))
-	pot := filepath.Join("pot", cleanName+".pot")
-	execCommand("xgettext", "--from-code=UTF-8", "--language="+lang, "--keyword=gettext", "--keyword=_", "--keyword=T", "--keyword=TN:1,2", "--force-po", "-o", pot, inputPath).Run()
-	stampPotHeader(pot, "")
-}
+// translateSegments traduz vários segmentos de uma vez: resolve o que já está
+// no cache, agrupa o restante (sem repetições) em lotes de até --batch-size
+// segmentos e --batch-chars caracteres e envia cada lote numa só chamada.
+// Um lote que falha em todos os motores é refeito segmento a segmento. As
+// traduções novas de cada lote vão ao --cache-url num só PUT.
+func translateSegments(texts []string, lang string, progress func(done, total int)) ([]string, []segmentOrigin) {
+	results := make([]string, len(texts))
+	origins := make([]segmentOrigin, len(texts))
+	chain := enginesFor(lang)
+	pending := make(map[string][]int)
+	var order []string
+	done := 0
+	lookup := func(i int) bool {
+		entry, ok := localCacheLookup(chain, lang, strings.TrimSpace(texts[i]))
+		if ok {
+			results[i], origins[i] = entry.Value, cacheOrigin(entry)
+			done++
+		}
+		return ok
+	}
+	// O que falta no cache local é pedido ao servidor num só lote.
+	var misses []int
+	var missing []string
+	seen := make(map[string]bool)
+	for i, text := range texts {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			done++
+			continue
+		}
+		if lookup(i) {
+			continue
+		}
+		misses = append(misses, i)
+		if !seen[text] {
+			seen[text] = true
+			missing = append(missing, text)
+		}
+	}
+	fetched := fetchRemoteEntries(chain, lang, missing)
+	for _, i := range misses {
+		if fetched && lookup(i) {
+			continue
+		}
+		text := strings.TrimSpace(texts[i])
+		norm := normalizeSource(text)
+		if _, seen := pending[norm]; !seen {
+			order = append(order, text)
+		}
+		pending[norm] = append(pending[norm], i)
+	}
+	progress(done, len(texts))
 
-func prepareGettextSelf(inputPath string) {
-	pot := filepath.Join("pot", _APP_+".pot")
-	execCommand("xgettext", "--from-code=UTF-8", "--keyword=T", "--keyword=TN:1,2", "--no-wrap", "-o", pot, inputPath).Run()
-	stampPotHeader(pot, "")
+	for start := 0; start < len(order); {
+		end, chars := start, 0
+		for end < len(order) && end-start < batchSize && (end == start || chars+len(order[end]) <= batchChars) {
+			chars += len(order[end])
+			end++
+		}
+		batch := order[start:end]
+		stored := make(map[string]CacheEntry)
+		res, from := translateBatch(chain, batch, lang, stored)
+		pushRemoteEntries(lang, stored)
+		for j := range batch {
+			for _, idx := range pending[normalizeSource(batch[j])] {
+				results[idx], origins[idx] = res[j], from[j]
+				done++
+			}
+		}
+		progress(done, len(texts))
+		start = end
+	}
+	return results, origins
 }
 
-func writeMsgfmtToMo(base, lang string) {
-	cleanBase := strings.TrimSuffix(base, filepath.Ext(base))
-	dir := filepath.Join("usr/share/locale", lang, "LC_MESSAGES")
-	os.MkdirAll(dir, 0755)
-	poFile := filepath.Join("pot", fmt.Sprintf("%s-%s.po", cleanBase, lang))
-	moFile := filepath.Join(dir, cleanBase+".mo")
-	execCommand("msgfmt", "-f", poFile, "-o", moFile).Run()
+func translateBatch(chain []Engine, batch []string, lang string, pending map[string]CacheEntry) ([]string, []segmentOrigin) {
+	results := make([]string, len(batch))
+	origins := make([]segmentOrigin, len(batch))
+	if len(batch) > 1 {
+		protected := make([]string, len(batch))
+		marks := make([]map[string]string, len(batch))
+		chars := 0
+		for i, text := range batch {
+			protected[i], marks[i] = protectVariables(text)
+			chars += len(protected[i])
+		}
+		for _, e := range chain {
+			if !isOnline && !isLocalEngine(e) {
+				continue
+			}
+			be, ok := e.(batchEngine)
+			if !ok {
+				// Sem API de lote: segue segmento a segmento, na ordem da cadeia.
+				break
+			}
+			var out []string
+			err := callEngine(e, lang, chars, func(ctx context.Context) error {
+				var errEng error
+				out, errEng = be.TranslateBatch(ctx, protected, sourceLang, lang)
+				return errEng
+			})
+			if errors.Is(err, errBatchSplit) {
+				break
+			}
+			if err != nil {
+				continue
+			}
+			for i, text := range batch {
+				results[i] = restoreVariables(strings.TrimSpace(out[i]), marks[i])
+				origins[i] = segmentOrigin{Engine: engineCacheKey(e)}
+				cacheStore(e, lang, text, results[i], pending)
+			}
+			return results, origins
+		}
+	}
+	for i, text := range batch {
+		results[i], origins[i] = translateSegment(text, lang, pending)
+	}
+	return results, origins
 }
 
-func parseFlags() {
-	pflag.Usage = usage
-	pflag.StringSliceVarP(&inputFiles, "inputfile", "i", nil, T("Arquivo fonte"))
-	pflag.StringVarP(&engine, "engine", "e", "google", T("Motor de tradução"))
-	pflag.StringVarP(&sourceLang, "source", "s", "auto", T("Idioma de origem"))
-	pflag.StringSliceVarP(&languages, "language", "l", nil, T("Idiomas destino"))
-	pflag.IntVarP(&jobs, "jobs", "j", 8, T("Traduções simultâneas"))
-	pflag.BoolVarP(&forceFlag, "force", "f", false, T("Ignora o cache"))
-	pflag.BoolVar(&cleanCacheFlag, "clean-cache", false, T("Limpa cache antigo"))
-	pflag.BoolVar(&selfFlag, "self", false, T("Extração especializada para o próprio chili-tradutor-go"))
-	pflag.BoolVar(&selfTestFlag, "self-test", false, T("Executa auto-teste de integridade"))
-	pflag.BoolVarP(&quietFlag, "quiet", "q", false, T("Modo silencioso"))
-	pflag.BoolVarP(&verboseFlag, "verbose", "v", false, T("Modo detalhado"))
-	pflag.BoolVarP(&versionFlag, "version", "V", false, T("Mostra versão"))
-	pflag.Parse()
-
-	targetLangs = defaultLanguages
-	if len(languages) > 0 {
-		if languages[0] == "all" {
-			targetLangs = supportedLanguages
+// callEngine executa fn respeitando o limite de vazão e o disjuntor do motor,
+// com até --retries tentativas separadas por backoff exponencial com jitter.
+// Cada tentativa recebe um contexto com o timeout do motor; tempo esgotado e
+// erros entram no relatório de falhas do idioma.
+func callEngine(e Engine, lang string, chars int, fn func(ctx context.Context) error) error {
+	g := guardFor(e)
+	timeout := engineTimeout(e)
+	var err error
+	for attempt := 0; attempt < retries; attempt++ {
+		if !g.breaker.allow() {
+			return errCircuitOpen
+		}
+		g.requests.wait(1)
+		g.chars.wait(float64(chars))
+		if appCtx.Err() != nil {
+			return appCtx.Err()
+		}
+		ctx, cancel := context.WithTimeout(appCtx, timeout)
+		err = fn(ctx)
+		timedOut := ctx.Err() == context.DeadlineExceeded
+		cancel()
+		if err == nil || errors.Is(err, errBatchSplit) {
+			// O motor respondeu; um lote mal dividido não é falha do motor.
+			g.breaker.success()
+			return err
+		}
+		if appCtx.Err() != nil {
+			return appCtx.Err()
+		}
+		if timedOut {
+			err = fmt.Errorf("%s: %s (%s)", e.Name(), T("tempo esgotado"), timeout)
+			recordFailure(lang, failTimeout)
 		} else {
-			targetLangs = languages
+			recordFailure(lang, failError)
+		}
+		g.breaker.failure()
+		if attempt < retries-1 {
+			sleepCtx(appCtx, backoffDelay(attempt))
 		}
 	}
-	langPositions = make(map[string]int)
-	for i, lang := range targetLangs {
-		langPositions[lang] = len(targetLangs) - i
-	}
+	return err
 }
 
-func setupEnvironment(ext, baseName, langName string) {
-	isMan, _ := regexp.MatchString(`^\.[1-9]$`, ext)
-	
-	if isMan {
-		os.MkdirAll("man", 0755)
-		return
+func engineTimeout(e Engine) time.Duration {
+	if callTimeout > 0 {
+		return callTimeout
+	}
+	if d, err := time.ParseDuration(config.Engines[e.Name()].Timeout); err == nil && d > 0 {
+		return d
 	}
+	return 60 * time.Second
+}
 
-	switch ext {
-	case ".md", ".markdown":
-		os.MkdirAll("doc", 0755)
-	case ".txt":
-		os.MkdirAll("txt", 0755)
-	case ".json":
-		os.MkdirAll("json", 0755)
-	case ".yaml", ".yml":
-		os.MkdirAll("yml", 0755)
-	case ".html", ".htm":
-		os.MkdirAll("html", 0755)
-	default:
-		os.MkdirAll("pot", 0755)
-		targetPot := filepath.Join("pot", baseName)
-		if ext == ".pot" {
-			absInput, _ := filepath.Abs(currentFile)
-			absTarget, _ := filepath.Abs(targetPot)
-			if absInput != absTarget {
-				copyFile(currentFile, targetPot)
-			}
-		} else {
-			if selfFlag {
-				prepareGettextSelf(currentFile)
-			} else {
-				prepareGettext(currentFile, baseName, langName)
-			}
-		}
+// --- RELATÓRIO DE FALHAS POR IDIOMA ---
+
+const (
+	failTimeout      = "timeout"
+	failError        = "erro"
+	failUntranslated = "sem tradução"
+)
+
+var (
+	langFailures = make(map[string]map[string]int)
+	moErrors     []string
+	muFailures   sync.Mutex
+)
+
+func recordFailure(lang, kind string) {
+	muFailures.Lock()
+	defer muFailures.Unlock()
+	if langFailures[lang] == nil {
+		langFailures[lang] = make(map[string]int)
 	}
+	langFailures[lang][kind]++
 }
 
-// --- FUNÇÕES DE TRADUÇÃO POR FORMATO ---
+// resetFailureReport zera o relatório para o próximo arquivo.
+func resetFailureReport() {
+	muFailures.Lock()
+	defer muFailures.Unlock()
+	langFailures = make(map[string]map[string]int)
+	moErrors = nil
+}
 
-func translateManPage(inputPath, lang string) {
-	content, _ := os.ReadFile(inputPath)
-	lines := strings.Split(string(content), "\n")
-	var translatedLines []string
+func recordMOError(path string, err error) {
+	muFailures.Lock()
+	defer muFailures.Unlock()
+	moErrors = append(moErrors, path+": "+err.Error())
+}
 
-	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		// Protege macros roff (linhas que começam com ponto)
-		if strings.HasPrefix(trimmed, ".") {
-			parts := strings.Fields(line)
-			if len(parts) > 1 {
-				macro := parts[0]
-				rest := strings.Join(parts[1:], " ")
-				// Traduz apenas o que vem depois da macro, se não for comentário
-				if !strings.HasPrefix(rest, `\"`) {
-					translated := callUniversalTranslator(rest, lang)
-					translatedLines = append(translatedLines, macro+" "+translated)
-				} else {
-					translatedLines = append(translatedLines, line)
-				}
-			} else {
-				translatedLines = append(translatedLines, line)
-			}
-		} else if trimmed == "" {
-			translatedLines = append(translatedLines, line)
-		} else {
-			translated := callUniversalTranslator(line, lang)
-			translatedLines = append(translatedLines, translated)
+func showFailureReport() {
+	muFailures.Lock()
+	defer muFailures.Unlock()
+	if len(langFailures) > 0 {
+		fmt.Printf("%s %s\n", red(T("[FALHAS]")), white(T("Tentativas que falharam, por idioma:")))
+		for _, lang := range sortedKeys(langFailures) {
+			f := langFailures[lang]
+			fmt.Printf("    → %-7s %s: %d | %s: %d | %s: %d\n", cyan(lang),
+				T("timeouts"), f[failTimeout], T("erros"), f[failError], T("sem tradução"), f[failUntranslated])
 		}
-
-		if i%10 == 0 || i == len(lines)-1 {
-			updateProgress(lang, i+1, len(lines), "MAN")
+	}
+	if len(moErrors) > 0 {
+		sort.Strings(moErrors)
+		fmt.Printf("%s %s\n", red(T("[MO]")), white(T("Mensagens deixadas fora do .mo:")))
+		for _, e := range moErrors {
+			fmt.Printf("    → %s\n", e)
 		}
 	}
-	
-	ext := filepath.Ext(inputPath)
-	base := strings.TrimSuffix(filepath.Base(inputPath), ext)
-	outFile := filepath.Join("man", fmt.Sprintf("%s-%s%s", base, lang, ext))
-	os.WriteFile(outFile, []byte(strings.Join(translatedLines, "\n")), 0644)
-	updateProgress(lang, len(lines), len(lines), "OK")
 }
 
-func translateHTML(inputPath, lang string) {
-	content, err := os.ReadFile(inputPath)
-	if err != nil { return }
-	lines := strings.Split(string(content), "\n")
-	var translatedLines []string
-	reTag := regexp.MustCompile(`(?s)<.*?>`)
+func sleepCtx(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
 
-	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" {
-			translatedLines = append(translatedLines, line)
-			continue
-		}
-		tagMap := make(map[string]string)
-		counter := 0
-		protected := reTag.ReplaceAllStringFunc(line, func(tag string) string {
-			placeholder := fmt.Sprintf("CHILI_HTML_%d_CHILI", counter)
-			tagMap[placeholder] = tag
-			counter++
-			return placeholder
-		})
-		textOnly := reTag.ReplaceAllString(line, "")
+// backoffDelay dobra a espera a cada tentativa (até --backoff-max) e sorteia
+// metade dela, para que goroutines de idiomas diferentes não voltem juntas.
+func backoffDelay(attempt int) time.Duration {
+	d := backoffBase << attempt
+	if d <= 0 || d > backoffMax {
+		d = backoffMax
+	}
+	if d < 2 {
+		return d
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)))
+}
+
+// --- LIMITE DE VAZÃO E DISJUNTOR ---
+
+var errCircuitOpen = errors.New("circuito aberto")
+
+// errBatchSplit indica que o motor respondeu, mas a resposta não se divide
+// nos segmentos do lote. Repetir daria o mesmo resultado: callEngine devolve
+// o erro na hora e translateBatch passa a traduzir segmento a segmento.
+var errBatchSplit = errors.New("lote")
+
+// engineGuard reúne o controle de vazão e o disjuntor de um motor,
+// compartilhados por todas as goroutines de idioma.
+type engineGuard struct {
+	requests *tokenBucket
+	chars    *tokenBucket
+	breaker  *circuitBreaker
+}
+
+var (
+	engineGuards = make(map[string]*engineGuard)
+	muGuards     sync.Mutex
+)
+
+func guardFor(e Engine) *engineGuard {
+	muGuards.Lock()
+	defer muGuards.Unlock()
+	if g, ok := engineGuards[e.Name()]; ok {
+		return g
+	}
+	cfg := config.Engines[e.Name()]
+	rps := rateLimit
+	if rps == 0 {
+		rps = cfg.RateLimit
+	}
+	cpm := charsPerMinute
+	if cpm == 0 {
+		cpm = cfg.CharsPerMinute
+	}
+	g := &engineGuard{breaker: &circuitBreaker{threshold: breakerLimit, cooldown: breakerCooling}}
+	if rps > 0 {
+		g.requests = newTokenBucket(rps, math.Max(1, rps))
+	}
+	if cpm > 0 {
+		g.chars = newTokenBucket(float64(cpm)/60, float64(cpm))
+	}
+	engineGuards[e.Name()] = g
+	return g
+}
+
+// tokenBucket é um balde de fichas simples; nil significa sem limite.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate, burst float64) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+func (b *tokenBucket) wait(n float64) {
+	if b == nil {
+		return
+	}
+	if n > b.burst {
+		n = b.burst
+	}
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+		if b.tokens >= n {
+			b.tokens -= n
+			b.mu.Unlock()
+			return
+		}
+		need := time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+		sleepCtx(appCtx, need)
+		if appCtx.Err() != nil {
+			return
+		}
+	}
+}
+
+// circuitBreaker pausa o motor após falhas consecutivas. Passado o cooldown,
+// uma única chamada de teste é liberada (meio aberto): se der certo o circuito
+// fecha, se falhar reabre na hora. trips conta as aberturas, não as falhas
+// das chamadas que já estavam em andamento quando o circuito abriu.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	open      bool
+	probing   bool
+	openUntil time.Time
+	trips     int
+}
+
+func (c *circuitBreaker) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.open {
+		return true
+	}
+	if c.probing || time.Now().Before(c.openUntil) {
+		return false
+	}
+	c.probing = true
+	return true
+}
+
+func (c *circuitBreaker) success() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures, c.open, c.probing = 0, false, false
+}
+
+func (c *circuitBreaker) failure() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case c.probing:
+		c.probing = false
+		c.openUntil = time.Now().Add(c.cooldown)
+		c.trips++
+	case c.open:
+	default:
+		c.failures++
+		if c.threshold > 0 && c.failures >= c.threshold {
+			c.open = true
+			c.openUntil = time.Now().Add(c.cooldown)
+			c.trips++
+		}
+	}
+}
+
+// normalizeSource tira espaços das pontas e junta espaços internos repetidos,
+// mas mantém maiúsculas: "Save" e "SAVE" são segmentos distintos.
+func normalizeSource(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// cacheKey monta a chave do esquema 2, única dentro do idioma de destino.
+func cacheKey(engineKey, from, text string) string {
+	return engineKey + "|" + from + "|" + normalizeSource(text)
+}
+
+// legacyCacheKey é a chave das entradas migradas do esquema 1, que não
+// registrava motor nem origem e guardava o texto em minúsculas.
+func legacyCacheKey(text string) string {
+	return "legacy|auto|" + strings.ToLower(strings.TrimSpace(text))
+}
+
+// cacheSourceText devolve o texto de origem da entrada; as migradas do
+// esquema 1 não o têm e mostram o da chave, em minúsculas.
+func cacheSourceText(key string, e CacheEntry) string {
+	if e.Source != "" {
+		return e.Source
+	}
+	if parts := strings.SplitN(key, "|", 3); len(parts) == 3 {
+		return parts[2]
+	}
+	return key
+}
+
+// humanEngine é o "motor" das traduções revisadas por pessoas (cache
+// import-po, cache set), que têm precedência sobre qualquer motor.
+const humanEngine = "human"
+
+// humanCacheKeys devolve as chaves humanas do texto: a do idioma de origem
+// atual e, com -s, também a de origem auto, onde o import-po grava por padrão.
+func humanCacheKeys(text string) []string {
+	keys := []string{cacheKey(humanEngine, sourceLang, text)}
+	if sourceLang != "auto" {
+		keys = append(keys, cacheKey(humanEngine, "auto", text))
+	}
+	return keys
+}
+
+// cacheLookup procura o segmento primeiro entre as traduções humanas e
+// depois no cache de cada motor da cadeia, na ordem, e então entre as
+// entradas migradas do esquema 1 (que casam sem diferenciar maiúsculas).
+// Com --cache-fallback aceita também a tradução de qualquer outro motor
+// para o mesmo texto e origem. O --force ignora o cache,
+// exceto as traduções humanas; o --force-human ignora tudo. Com
+// --cache-url, o que falta localmente é buscado no servidor.
+func cacheLookup(chain []Engine, lang, text string) (CacheEntry, bool) {
+	if entry, ok := localCacheLookup(chain, lang, text); ok {
+		return entry, true
+	}
+	if fetchRemoteEntries(chain, lang, []string{text}) {
+		return localCacheLookup(chain, lang, text)
+	}
+	return CacheEntry{}, false
+}
+
+// remoteBatchChars limita o tamanho das chaves de cada GET ao servidor, para
+// a URL não passar do que proxies costumam aceitar.
+const remoteBatchChars = 4000
+
+// fetchRemoteEntries busca no servidor as chaves de todos os textos de uma
+// vez, em poucos GETs, e grava localmente o que vier. Devolve true se algo
+// foi encontrado.
+func fetchRemoteEntries(chain []Engine, lang string, texts []string) bool {
+	if remote == nil || forceHumanFlag {
+		return false
+	}
+	var keys []string
+	for _, text := range texts {
+		keys = append(keys, humanCacheKeys(text)...)
+		if !forceFlag {
+			for _, e := range chain {
+				keys = append(keys, cacheKey(engineCacheKey(e), sourceLang, text))
+			}
+		}
+	}
+	fetched := false
+	for start := 0; start < len(keys); {
+		end, chars := start, 0
+		for end < len(keys) && (end == start || chars+len(keys[end]) <= remoteBatchChars) {
+			chars += len(keys[end])
+			end++
+		}
+		found := remote.get(lang, keys[start:end])
+		mu.Lock()
+		storage.batch(func() {
+			for key, e := range found {
+				mergeRemoteEntry(lang, key, e)
+				fetched = true
+			}
+		})
+		mu.Unlock()
+		start = end
+	}
+	return fetched
+}
+
+func localCacheLookup(chain []Engine, lang, text string) (CacheEntry, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	if forceHumanFlag {
+		return CacheEntry{}, false
+	}
+	for _, key := range humanCacheKeys(text) {
+		if entry, ok := cacheHit(lang, key); ok {
+			return entry, true
+		}
+	}
+	for _, e := range chain {
+		key := cacheKey(engineCacheKey(e), sourceLang, text)
+		if entry, ok := storage.get(lang, key); ok && (!forceFlag || entry.Human) {
+			return useCacheEntry(lang, key, entry), true
+		}
+	}
+	if forceFlag {
+		return CacheEntry{}, false
+	}
+	if entry, ok := cacheHit(lang, legacyCacheKey(text)); ok {
+		return entry, true
+	}
+	if !cacheFallback {
+		return CacheEntry{}, false
+	}
+	if key, entry, ok := storage.findText(lang, sourceLang, normalizeSource(text)); ok {
+		return useCacheEntry(lang, key, entry), true
+	}
+	return CacheEntry{}, false
+}
+
+func cacheHit(lang, key string) (CacheEntry, bool) {
+	entry, exists := storage.get(lang, key)
+	if !exists {
+		return CacheEntry{}, false
+	}
+	return useCacheEntry(lang, key, entry), true
+}
+
+func useCacheEntry(lang, key string, entry CacheEntry) CacheEntry {
+	entry.LastUsed = time.Now()
+	storage.touch(lang, key, entry.LastUsed)
+	cacheHits++
+	return entry
+}
+
+// cacheStore grava a tradução nova no cache local e, com --cache-url, a
+// acrescenta a pending; o chamador envia o lote com pushRemoteEntries.
+func cacheStore(e Engine, lang, text, value string, pending map[string]CacheEntry) {
+	engineKey := engineCacheKey(e)
+	key := cacheKey(engineKey, sourceLang, text)
+	entry, stored := cacheStoreLocal(e, lang, key, engineKey, text, value)
+	if stored && remote != nil {
+		pending[key] = entry
+	}
+}
+
+// pushRemoteEntries envia ao servidor, num só PUT, as traduções novas de um
+// idioma.
+func pushRemoteEntries(lang string, pending map[string]CacheEntry) {
+	if remote != nil && len(pending) > 0 {
+		remote.put(lang, pending)
+	}
+}
+
+func cacheStoreLocal(e Engine, lang, key, engineKey, text, value string) (CacheEntry, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	netCalls++
+	engineServed[e.Name()]++
+	// Uma correção humana feita sobre a entrada do motor não é substituída.
+	if old, ok := storage.get(lang, key); ok && old.Human && !forceHumanFlag {
+		return CacheEntry{}, false
+	}
+	entry := CacheEntry{Value: value, LastUsed: time.Now(), Source: normalizeSource(text), From: sourceLang, Engine: engineKey}
+	storage.put(lang, key, entry)
+	return entry, true
+}
+
+// --- MOTORES DE TRADUÇÃO ---
+
+// Engine é o contrato de um backend de tradução. Cache, proteção de variáveis
+// e novas tentativas ficam em callUniversalTranslator; o motor só traduz.
+// Os códigos de idioma chegam no formato do chili (pt_BR, zh_CN, auto) e
+// cada motor converte para o que o seu backend espera.
+type Engine interface {
+	Name() string
+	Translate(ctx context.Context, text, from, to string) (string, error)
+	Languages(ctx context.Context) ([]string, error)
+	Health(ctx context.Context) error
+}
+
+// batchEngine é implementado pelos motores cujo backend traduz vários textos
+// numa só chamada. Os demais recebem os segmentos um a um, cada um com o seu
+// próprio timeout.
+type batchEngine interface {
+	TranslateBatch(ctx context.Context, texts []string, from, to string) ([]string, error)
+}
+
+// localEngine é implementado pelos motores que não dependem da internet
+// pública (instâncias próprias ou programas locais).
+type localEngine interface {
+	Local() bool
+}
+
+// cacheKeyEngine é implementado pelos motores cujo resultado depende de
+// opções além do nome (modelo do LLM, formalidade do DeepL...). Essas opções
+// entram na chave do cache.
+type cacheKeyEngine interface {
+	CacheKey() string
+}
+
+var (
+	engineRegistry  = make(map[string]func() (Engine, error))
+	engineInstances = make(map[string]Engine)
+	activeEngines   []Engine
+	engineRoutes    = make(map[string]Engine)
+	engineServed    = make(map[string]int)
+	httpClient      = &http.Client{}
+)
+
+func init() {
+	for _, backend := range []string{"google", "bing", "yandex", "apertium"} {
+		b := backend
+		registerEngine(b, func() (Engine, error) { return &transShellEngine{backend: b}, nil })
+	}
+	registerEngine("libretranslate", func() (Engine, error) {
+		cfg := config.Engines["libretranslate"]
+		return newLibreTranslateEngine(
+			firstNonEmpty(endpoint, cfg.Endpoint),
+			firstNonEmpty(apiKey, os.Getenv("LIBRETRANSLATE_API_KEY"), cfg.APIKey),
+		), nil
+	})
+	registerEngine("deepl", func() (Engine, error) {
+		cfg := config.Engines["deepl"]
+		glossary := make(map[string]string)
+		if len(cfg.Glossary) > 0 && sourceLang == "auto" {
+			// Só o --glossary explícito é erro; o da configuração é ignorado.
+			fmt.Fprintf(os.Stderr, "%s %s\n", yellow(T("[AVISO]")), white(T("deepl: glossários da configuração ignorados sem o idioma de origem (-s)")))
+		} else {
+			for lang, id := range cfg.Glossary {
+				glossary[lang] = id
+			}
+		}
+		for _, g := range glossaries {
+			if lang, id, ok := strings.Cut(g, "="); ok {
+				glossary[lang] = id
+			} else {
+				glossary["*"] = g
+			}
+		}
+		return newDeepLEngine(
+			firstNonEmpty(endpoint, cfg.Endpoint),
+			firstNonEmpty(apiKey, os.Getenv("DEEPL_API_KEY"), os.Getenv("DEEPL_AUTH_KEY"), cfg.APIKey),
+			firstNonEmpty(formality, cfg.Formality),
+			glossary,
+		)
+	})
+	for _, api := range []string{"llm", "ollama"} {
+		a := api
+		registerEngine(a, func() (Engine, error) {
+			cfg := config.Engines[a]
+			prompt := cfg.Prompt
+			if promptFile != "" {
+				data, err := os.ReadFile(promptFile)
+				if err != nil {
+					return nil, err
+				}
+				prompt = string(data)
+			}
+			return newLLMEngine(
+				a,
+				firstNonEmpty(endpoint, cfg.Endpoint),
+				firstNonEmpty(apiKey, os.Getenv("OPENAI_API_KEY"), cfg.APIKey),
+				firstNonEmpty(model, cfg.Model),
+				prompt,
+			)
+		})
+	}
+}
+
+func registerEngine(name string, factory func() (Engine, error)) {
+	engineRegistry[name] = factory
+}
+
+func newEngine(name string) (Engine, error) {
+	factory, ok := engineRegistry[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, fmt.Errorf(T("motor desconhecido: %s (disponíveis: %s)"), name, strings.Join(engineNames(), ", "))
+	}
+	return factory()
+}
+
+// getEngine devolve a instância compartilhada do motor, criando-a na
+// primeira vez, para que cadeia e rotas usem o mesmo objeto.
+func getEngine(name string) (Engine, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if e, ok := engineInstances[name]; ok {
+		return e, nil
+	}
+	e, err := newEngine(name)
+	if err != nil {
+		return nil, err
+	}
+	engineInstances[name] = e
+	return e, nil
+}
+
+// setupEngines monta a cadeia de fallback de --engine (ex: google,bing,yandex)
+// e a tabela de rotas por idioma (--route ru=yandex e "routes" da configuração).
+func setupEngines() error {
+	activeEngines = nil
+	for _, name := range strings.Split(engine, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		e, err := getEngine(name)
+		if err != nil {
+			return err
+		}
+		activeEngines = append(activeEngines, e)
+	}
+	if len(activeEngines) == 0 {
+		return errors.New(T("nenhum motor informado em --engine"))
+	}
+
+	table := make(map[string]string)
+	for lang, name := range config.Routes {
+		table[lang] = name
+	}
+	for _, r := range routes {
+		lang, name, ok := strings.Cut(r, "=")
+		if !ok {
+			return fmt.Errorf(T("rota inválida: %s (use idioma=motor)"), r)
+		}
+		table[strings.TrimSpace(lang)] = name
+	}
+	for lang, name := range table {
+		e, err := getEngine(name)
+		if err != nil {
+			return err
+		}
+		engineRoutes[lang] = e
+	}
+	return nil
+}
+
+// enginesFor devolve a ordem de tentativa para um idioma: o motor da rota,
+// se houver, seguido da cadeia de --engine.
+func enginesFor(lang string) []Engine {
+	routed, ok := engineRoutes[lang]
+	if !ok {
+		return activeEngines
+	}
+	chain := []Engine{routed}
+	for _, e := range activeEngines {
+		if e != routed {
+			chain = append(chain, e)
+		}
+	}
+	return chain
+}
+
+func engineCacheKey(e Engine) string {
+	if c, ok := e.(cacheKeyEngine); ok {
+		return c.CacheKey()
+	}
+	return e.Name()
+}
+
+func isLocalEngine(e Engine) bool {
+	l, ok := e.(localEngine)
+	return ok && l.Local()
+}
+
+func engineNames() []string {
+	return sortedKeys(engineRegistry)
+}
+
+// transShellEngine delega ao translate-shell (trans) usando um dos seus backends.
+type transShellEngine struct {
+	backend string
+}
+
+func (e *transShellEngine) Name() string { return e.backend }
+
+func (e *transShellEngine) Translate(ctx context.Context, text, from, to string) (string, error) {
+	cmd := execCommandContext(ctx, "trans", "-e", e.backend, "-s", transLangCode(from), "-no-init", "-no-autocorrect", "-b", ":"+transLangCode(to))
+	cmd.Stdin = strings.NewReader(text)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// TranslateBatch envia o lote inteiro num só processo trans, separando os
+// segmentos com marcadores numerados que são conferidos na volta.
+func (e *transShellEngine) TranslateBatch(ctx context.Context, texts []string, from, to string) ([]string, error) {
+	if len(texts) == 1 {
+		res, err := e.Translate(ctx, texts[0], from, to)
+		return []string{res}, err
+	}
+	cmd := execCommandContext(ctx, "trans", "-e", e.backend, "-s", transLangCode(from), "-no-init", "-no-autocorrect", "-join-sentence", "-b", ":"+transLangCode(to))
+	cmd.Stdin = strings.NewReader(joinBatch(texts))
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	return splitBatch(string(out), len(texts))
+}
+
+func (e *transShellEngine) Languages(ctx context.Context) ([]string, error) {
+	out, err := execCommandContext(ctx, "trans", "-e", e.backend, "-list-codes").Output()
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(out)), nil
+}
+
+func (e *transShellEngine) Health(ctx context.Context) error {
+	_, err := exec.LookPath("trans")
+	return err
+}
+
+var reBatchSeparator = regexp.MustCompile(`\s*CHILI_SEP_(\d+)_CHILI\s*`)
+
+func joinBatch(texts []string) string {
+	var b strings.Builder
+	for i, text := range texts {
+		if i > 0 {
+			fmt.Fprintf(&b, "\nCHILI_SEP_%d_CHILI\n", i)
+		}
+		b.WriteString(text)
+	}
+	return b.String()
+}
+
+func splitBatch(out string, n int) ([]string, error) {
+	out = strings.TrimSpace(out)
+	matches := reBatchSeparator.FindAllStringSubmatchIndex(out, -1)
+	if len(matches) != n-1 {
+		return nil, fmt.Errorf("%w: "+T("%d segmentos enviados, %d recebidos"), errBatchSplit, n, len(matches)+1)
+	}
+	parts := make([]string, 0, n)
+	last := 0
+	for i, m := range matches {
+		if out[m[2]:m[3]] != fmt.Sprint(i+1) {
+			return nil, fmt.Errorf("%w: "+T("separador fora de ordem: %s"), errBatchSplit, out[m[0]:m[1]])
+		}
+		parts = append(parts, out[last:m[0]])
+		last = m[1]
+	}
+	return append(parts, out[last:]), nil
+}
+
+func transLangCode(lang string) string {
+	return strings.ReplaceAll(lang, "_", "-")
+}
+
+// libreTranslateEngine fala diretamente com a API REST do LibreTranslate.
+type libreTranslateEngine struct {
+	endpoint string
+	apiKey   string
+	mu       sync.Mutex
+	codes    map[string]bool
+}
+
+// Códigos do chili que o LibreTranslate conhece por outro nome. O último
+// candidato é usado quando não foi possível consultar /languages.
+var libreLangAliases = map[string][]string{
+	"pt_BR": {"pt-BR", "pt"},
+	"pt_PT": {"pt-PT", "pt"},
+	"zh_CN": {"zh-Hans", "zh"},
+	"zh_TW": {"zh-Hant", "zt"},
+	"no":    {"no", "nb"},
+}
+
+func newLibreTranslateEngine(endpoint, apiKey string) *libreTranslateEngine {
+	if endpoint == "" {
+		endpoint = "http://localhost:5000"
+	}
+	return &libreTranslateEngine{endpoint: strings.TrimRight(endpoint, "/"), apiKey: apiKey}
+}
+
+func (e *libreTranslateEngine) Name() string { return "libretranslate" }
+
+// Local diz se o servidor está nesta máquina ou na rede local, para que o
+// motor continue sendo usado sem internet.
+func (e *libreTranslateEngine) Local() bool { return isLocalEndpoint(e.endpoint) }
+
+func isLocalEndpoint(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" || strings.HasSuffix(host, ".local") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast())
+}
+
+func (e *libreTranslateEngine) Translate(ctx context.Context, text, from, to string) (string, error) {
+	res, err := e.TranslateBatch(ctx, []string{text}, from, to)
+	if err != nil {
+		return "", err
+	}
+	return res[0], nil
+}
+
+func (e *libreTranslateEngine) TranslateBatch(ctx context.Context, texts []string, from, to string) ([]string, error) {
+	payload := map[string]interface{}{
+		"q":      texts,
+		"source": e.langCode(ctx, from),
+		"target": e.langCode(ctx, to),
+		"format": "text",
+	}
+	if e.apiKey != "" {
+		payload["api_key"] = e.apiKey
+	}
+	var resp struct {
+		TranslatedText []string `json:"translatedText"`
+	}
+	if err := postJSON(ctx, e.endpoint+"/translate", payload, nil, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.TranslatedText) != len(texts) {
+		return nil, fmt.Errorf("libretranslate: %w: %d textos enviados, %d recebidos", errBatchSplit, len(texts), len(resp.TranslatedText))
+	}
+	return resp.TranslatedText, nil
+}
+
+func (e *libreTranslateEngine) Languages(ctx context.Context) ([]string, error) {
+	u := e.endpoint + "/languages"
+	if e.apiKey != "" {
+		u += "?api_key=" + url.QueryEscape(e.apiKey)
+	}
+	var langs []struct {
+		Code string `json:"code"`
+	}
+	if err := getJSON(ctx, u, nil, &langs); err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, len(langs))
+	for _, l := range langs {
+		codes = append(codes, l.Code)
+	}
+	return codes, nil
+}
+
+func (e *libreTranslateEngine) Health(ctx context.Context) error {
+	_, err := e.Languages(ctx)
+	return err
+}
+
+func (e *libreTranslateEngine) langCode(ctx context.Context, lang string) string {
+	if lang == "auto" {
+		return lang
+	}
+	// A lista é buscada até a primeira resposta; uma falha não fica guardada.
+	e.mu.Lock()
+	if e.codes == nil {
+		if codes, err := e.Languages(ctx); err == nil {
+			e.codes = make(map[string]bool)
+			for _, c := range codes {
+				e.codes[c] = true
+			}
+		}
+	}
+	known := e.codes
+	e.mu.Unlock()
+	candidates, ok := libreLangAliases[lang]
+	if !ok {
+		candidates = []string{strings.ToLower(strings.SplitN(lang, "_", 2)[0])}
+	}
+	for _, c := range candidates {
+		if known[c] {
+			return c
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+// deeplEngine usa a API REST v2 do DeepL. Chaves terminadas em ":fx"
+// pertencem ao plano gratuito e usam api-free.deepl.com.
+type deeplEngine struct {
+	endpoint  string
+	apiKey    string
+	formality string
+	glossary  map[string]string
+}
+
+const (
+	deeplFreeEndpoint = "https://api-free.deepl.com"
+	deeplProEndpoint  = "https://api.deepl.com"
+)
+
+var deeplFormalities = []string{"default", "more", "less", "prefer_more", "prefer_less"}
+
+// Idiomas de destino cujo código no DeepL não é apenas o prefixo em maiúsculas.
+var deeplTargetCodes = map[string]string{
+	"en":    "EN-US",
+	"pt_BR": "PT-BR",
+	"pt_PT": "PT-PT",
+	"zh_CN": "ZH-HANS",
+	"zh_TW": "ZH-HANT",
+	"no":    "NB",
+}
+
+func newDeepLEngine(endpoint, apiKey, formality string, glossary map[string]string) (*deeplEngine, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf(T("deepl: chave de API ausente (use --api-key, DEEPL_API_KEY ou %s)"), configFile)
+	}
+	if endpoint == "" {
+		endpoint = deeplProEndpoint
+		if strings.HasSuffix(apiKey, ":fx") {
+			endpoint = deeplFreeEndpoint
+		}
+	}
+	if formality != "" && !containsString(deeplFormalities, formality) {
+		return nil, fmt.Errorf(T("deepl: formalidade inválida: %s (use %s)"), formality, strings.Join(deeplFormalities, ", "))
+	}
+	if len(glossary) > 0 && sourceLang == "auto" {
+		return nil, errors.New(T("deepl: glossários exigem o idioma de origem (-s)"))
+	}
+	return &deeplEngine{
+		endpoint:  strings.TrimRight(endpoint, "/"),
+		apiKey:    apiKey,
+		formality: formality,
+		glossary:  glossary,
+	}, nil
+}
+
+func (e *deeplEngine) Name() string { return "deepl" }
+
+func (e *deeplEngine) Translate(ctx context.Context, text, from, to string) (string, error) {
+	res, err := e.TranslateBatch(ctx, []string{text}, from, to)
+	if err != nil {
+		return "", err
+	}
+	return res[0], nil
+}
+
+func (e *deeplEngine) TranslateBatch(ctx context.Context, texts []string, from, to string) ([]string, error) {
+	payload := map[string]interface{}{
+		"text":        texts,
+		"target_lang": deeplTargetCode(to),
+	}
+	if from != "auto" {
+		payload["source_lang"] = strings.ToUpper(strings.SplitN(from, "_", 2)[0])
+	}
+	if f := deeplFormality(e.formality); f != "" {
+		payload["formality"] = f
+	}
+	if id := firstNonEmpty(e.glossary[to], e.glossary["*"]); id != "" {
+		payload["glossary_id"] = id
+	}
+	var resp struct {
+		Translations []struct {
+			Text string `json:"text"`
+		} `json:"translations"`
+	}
+	if err := postJSON(ctx, e.endpoint+"/v2/translate", payload, e.headers(), &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Translations) != len(texts) {
+		return nil, fmt.Errorf("deepl: %w: %d textos enviados, %d recebidos", errBatchSplit, len(texts), len(resp.Translations))
+	}
+	results := make([]string, len(texts))
+	for i, t := range resp.Translations {
+		results[i] = t.Text
+	}
+	return results, nil
+}
+
+func (e *deeplEngine) Languages(ctx context.Context) ([]string, error) {
+	var langs []struct {
+		Language string `json:"language"`
+	}
+	if err := getJSON(ctx, e.endpoint+"/v2/languages?type=target", e.headers(), &langs); err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, len(langs))
+	for _, l := range langs {
+		codes = append(codes, l.Language)
+	}
+	return codes, nil
+}
+
+func (e *deeplEngine) Health(ctx context.Context) error {
+	var usage map[string]interface{}
+	return getJSON(ctx, e.endpoint+"/v2/usage", e.headers(), &usage)
+}
+
+func (e *deeplEngine) CacheKey() string {
+	key := "deepl"
+	if e.formality != "" {
+		key += ":" + e.formality
+	}
+	for _, lang := range sortedKeys(e.glossary) {
+		key += ":" + lang + "=" + e.glossary[lang]
+	}
+	return key
+}
+
+// deeplFormality devolve o valor mandado à API. O DeepL responde 400 a
+// "more"/"less" para idiomas sem formalidade (EN, ZH, JA...), então eles
+// viram "prefer_more"/"prefer_less", que valem para todos; "default" é omitido.
+func deeplFormality(formality string) string {
+	switch formality {
+	case "more", "less":
+		return "prefer_" + formality
+	case "default":
+		return ""
+	}
+	return formality
+}
+
+func (e *deeplEngine) headers() map[string]string {
+	return map[string]string{"Authorization": "DeepL-Auth-Key " + e.apiKey}
+}
+
+func deeplTargetCode(lang string) string {
+	if code, ok := deeplTargetCodes[lang]; ok {
+		return code
+	}
+	return strings.ToUpper(strings.SplitN(lang, "_", 2)[0])
+}
+
+// llmEngine traduz com um modelo de linguagem servido localmente. O modo
+// "llm" usa a API compatível com OpenAI (/v1/chat/completions, atendida por
+// Ollama, llama.cpp, LM Studio, vLLM...) e o modo "ollama" usa /api/chat.
+type llmEngine struct {
+	api      string
+	endpoint string
+	apiKey   string
+	model    string
+	prompt   *template.Template
+}
+
+// llmPromptData é o que fica disponível para o template do prompt.
+type llmPromptData struct {
+	Source       string
+	Target       string
+	Text         string
+	Placeholders []string
+}
+
+const defaultLLMPrompt = `You are a professional software localization translator.
+Translate the text below {{if ne .Source "auto"}}from the "{{.Source}}" locale {{end}}into the "{{.Target}}" locale.
+Reply with the translated text only: no explanations, notes or surrounding quotes.
+{{- if .Placeholders}}
+Keep these tokens exactly as written, untranslated and in a sensible position: {{join .Placeholders ", "}}.
+{{- end}}
+
+{{.Text}}`
+
+var rePlaceholder = regexp.MustCompile(`CHILI_[A-Z]+_\d+_CHILI`)
+
+func newLLMEngine(api, endpoint, apiKey, model, prompt string) (*llmEngine, error) {
+	if endpoint == "" {
+		endpoint = "http://localhost:11434"
+	}
+	if model == "" {
+		model = "llama3.1"
+	}
+	if prompt == "" {
+		prompt = defaultLLMPrompt
+	}
+	tmpl, err := template.New("prompt").Funcs(template.FuncMap{"join": strings.Join}).Parse(prompt)
+	if err != nil {
+		return nil, fmt.Errorf(T("%s: template de prompt inválido: %v"), api, err)
+	}
+	return &llmEngine{
+		api:      api,
+		endpoint: strings.TrimRight(endpoint, "/"),
+		apiKey:   apiKey,
+		model:    model,
+		prompt:   tmpl,
+	}, nil
+}
+
+func (e *llmEngine) Name() string { return e.api }
+
+// Local vale para um servidor nesta máquina ou na rede local (Ollama,
+// llama.cpp); uma API na internet não funciona sem conexão.
+func (e *llmEngine) Local() bool { return isLocalEndpoint(e.endpoint) }
+
+func (e *llmEngine) CacheKey() string { return e.api + ":" + e.model }
+
+func (e *llmEngine) Translate(ctx context.Context, text, from, to string) (string, error) {
+	var prompt strings.Builder
+	data := llmPromptData{Source: from, Target: to, Text: text, Placeholders: rePlaceholder.FindAllString(text, -1)}
+	if err := e.prompt.Execute(&prompt, data); err != nil {
+		return "", err
+	}
+	messages := []map[string]string{{"role": "user", "content": prompt.String()}}
+
+	var content string
+	if e.api == "ollama" {
+		var resp struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		}
+		payload := map[string]interface{}{
+			"model":    e.model,
+			"messages": messages,
+			"stream":   false,
+			"options":  map[string]interface{}{"temperature": 0},
+		}
+		if err := postJSON(ctx, e.endpoint+"/api/chat", payload, e.headers(), &resp); err != nil {
+			return "", err
+		}
+		content = resp.Message.Content
+	} else {
+		var resp struct {
+			Choices []struct {
+				Message struct {
+					Content string `json:"content"`
+				} `json:"message"`
+			} `json:"choices"`
+		}
+		payload := map[string]interface{}{
+			"model":       e.model,
+			"messages":    messages,
+			"temperature": 0,
+		}
+		if err := postJSON(ctx, e.endpoint+"/v1/chat/completions", payload, e.headers(), &resp); err != nil {
+			return "", err
+		}
+		if len(resp.Choices) == 0 {
+			return "", fmt.Errorf("%s: resposta sem choices", e.api)
+		}
+		content = resp.Choices[0].Message.Content
+	}
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", fmt.Errorf("%s: resposta vazia", e.api)
+	}
+	return content, nil
+}
+
+// Um modelo de linguagem não tem lista fixa de idiomas; assume os do chili.
+func (e *llmEngine) Languages(ctx context.Context) ([]string, error) {
+	return supportedLanguages, nil
+}
+
+func (e *llmEngine) Health(ctx context.Context) error {
+	var out map[string]interface{}
+	if e.api == "ollama" {
+		return getJSON(ctx, e.endpoint+"/api/tags", e.headers(), &out)
+	}
+	return getJSON(ctx, e.endpoint+"/v1/models", e.headers(), &out)
+}
+
+func (e *llmEngine) headers() map[string]string {
+	if e.apiKey == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + e.apiKey}
+}
+
+// commandEngine executa um programa externo definido em "commands" no
+// arquivo de configuração, com o mesmo isolamento de locale de execCommand.
+type commandEngine struct {
+	name    string
+	cfg     CommandConfig
+	timeout time.Duration
+	output  func(string) (string, error)
+}
+
+func registerCommandEngines() {
+	for name, cfg := range config.Commands {
+		n, c := name, cfg
+		registerEngine(n, func() (Engine, error) { return newCommandEngine(n, c) })
+	}
+}
+
+func newCommandEngine(name string, cfg CommandConfig) (*commandEngine, error) {
+	if cfg.Command == "" {
+		return nil, fmt.Errorf(T("%s: campo \"command\" ausente"), name)
+	}
+	e := &commandEngine{name: name, cfg: cfg}
+	if cfg.Timeout != "" {
+		d, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("%s: timeout: %v", name, err)
+		}
+		e.timeout = d
+	}
+	switch cfg.Input {
+	case "", "stdin":
+	case "argv":
+		if !strings.Contains(strings.Join(cfg.Args, " "), "{text}") {
+			return nil, fmt.Errorf(T("%s: input \"argv\" exige {text} em args"), name)
+		}
+	default:
+		return nil, fmt.Errorf(T("%s: input inválido: %s (use stdin ou argv)"), name, cfg.Input)
+	}
+
+	kind, arg, _ := strings.Cut(cfg.Output, ":")
+	switch kind {
+	case "", "text":
+		e.output = func(out string) (string, error) { return strings.TrimSpace(out), nil }
+	case "json":
+		e.output = func(out string) (string, error) { return jsonField(out, arg) }
+	case "regex":
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: output: %v", name, err)
+		}
+		e.output = func(out string) (string, error) {
+			m := re.FindStringSubmatch(out)
+			if len(m) < 2 {
+				return "", fmt.Errorf("%s: saída não casa com %s", name, arg)
+			}
+			return strings.TrimSpace(m[1]), nil
+		}
+	default:
+		return nil, fmt.Errorf(T("%s: output inválido: %s (use text, json:<campo> ou regex:<expr>)"), name, cfg.Output)
+	}
+	return e, nil
+}
+
+func (e *commandEngine) Name() string { return e.name }
+
+func (e *commandEngine) Local() bool { return true }
+
+func (e *commandEngine) Translate(ctx context.Context, text, from, to string) (string, error) {
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+
+	r := strings.NewReplacer("{src}", e.langCode(from), "{tgt}", e.langCode(to), "{text}", text)
+	args := make([]string, len(e.cfg.Args))
+	for i, a := range e.cfg.Args {
+		args[i] = r.Replace(a)
+	}
+	cmd := execCommandContext(ctx, e.cfg.Command, args...)
+	if e.cfg.Input != "argv" {
+		cmd.Stdin = strings.NewReader(text)
+	}
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("%s: %s (%s)", e.name, T("tempo esgotado"), e.timeout)
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%s: %v: %s", e.name, err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("%s: %v", e.name, err)
+	}
+	return e.output(string(out))
+}
+
+func (e *commandEngine) Languages(ctx context.Context) ([]string, error) {
+	if len(e.cfg.Languages) == 0 {
+		return supportedLanguages, nil
+	}
+	langs := make([]string, 0, len(e.cfg.Languages))
+	for lang := range e.cfg.Languages {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs, nil
+}
+
+func (e *commandEngine) Health(ctx context.Context) error {
+	_, err := exec.LookPath(e.cfg.Command)
+	return err
+}
+
+func (e *commandEngine) langCode(lang string) string {
+	if code, ok := e.cfg.Languages[lang]; ok {
+		return code
+	}
+	return lang
+}
+
+// jsonField extrai um campo texto de um JSON, usando pontos para descer
+// em objetos e índices numéricos para listas (ex: "data.0.text").
+func jsonField(data, path string) (string, error) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		return "", err
+	}
+	for _, part := range strings.Split(path, ".") {
+		if part == "" {
+			continue
+		}
+		switch node := v.(type) {
+		case map[string]interface{}:
+			v = node[part]
+		case []interface{}:
+			var idx int
+			if _, err := fmt.Sscanf(part, "%d", &idx); err != nil || idx < 0 || idx >= len(node) {
+				return "", fmt.Errorf("json: índice inválido: %s", part)
+			}
+			v = node[idx]
+		default:
+			return "", fmt.Errorf("json: campo não encontrado: %s", path)
+		}
+	}
+	str, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("json: campo não é texto: %s", path)
+	}
+	return strings.TrimSpace(str), nil
+}
+
+// --- UTILITÁRIOS HTTP DOS MOTORES ---
+
+func getJSON(ctx context.Context, u string, headers map[string]string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	return doJSON(req, headers, out)
+}
+
+func postJSON(ctx context.Context, u string, payload interface{}, headers map[string]string, out interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return doJSON(req, headers, out)
+}
+
+func doJSON(req *http.Request, headers map[string]string, out interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return json.Unmarshal(data, out)
+}
+
+func prepareGettext(inputPath, baseName, lang string) {
+	cleanName := strings.TrimSuffix(baseName, filepath.Ext(baseName))
+	pot := filepath.Join("pot", cleanName+".pot")
+	execCommand("xgettext", "--from-code=UTF-8", "--language="+lang, "--keyword=gettext", "--keyword=_", "--keyword=T", "--keyword=TN:1,2", "--force-po", "-o", pot, inputPath).Run()
+	stampPotHeader(pot, "")
+}
+
+func prepareGettextSelf(inputPath string) {
+	pot := filepath.Join("pot", _APP_+".pot")
+	execCommand("xgettext", "--from-code=UTF-8", "--keyword=T", "--keyword=TN:1,2", "--no-wrap", "-o", pot, inputPath).Run()
+	stampPotHeader(pot, "")
+}
+
+// writeMoFile compila pot/<base>-<lang>.po no .mo do idioma. Mensagens
+// inválidas ficam de fora e vão para o relatório de falhas.
+func writeMoFile(base, lang string) {
+	cleanBase := strings.TrimSuffix(base, filepath.Ext(base))
+	dir := filepath.Join("usr/share/locale", lang, "LC_MESSAGES")
+	os.MkdirAll(dir, 0755)
+	poFile := filepath.Join("pot", fmt.Sprintf("%s-%s.po", cleanBase, lang))
+	moFile := filepath.Join(dir, cleanBase+".mo")
+	po, err := readPOFile(poFile)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		recordMOError(poFile, err)
+		return
+	}
+	// Com --mark-fuzzy as traduções do motor ficam fora do .mo até a revisão.
+	msgs, errs := compileMO(po, !markFuzzyFlag)
+	for _, err := range errs {
+		recordMOError(poFile, err)
+	}
+	order := binary.ByteOrder(binary.LittleEndian)
+	if moEndianness == "big" {
+		order = binary.BigEndian
+	}
+	var buf bytes.Buffer
+	writeMO(&buf, msgs, order)
+	if err := writeFileAtomic(moFile, buf.Bytes(), 0644); err != nil {
+		recordMOError(moFile, err)
+	}
+}
+
+func parseFlags() {
+	pflag.Usage = usage
+	pflag.StringSliceVarP(&inputFiles, "inputfile", "i", nil, T("Arquivo fonte"))
+	pflag.StringVarP(&engine, "engine", "e", "google", T("Motor de tradução"))
+	pflag.StringSliceVar(&routes, "route", nil, T("Motor preferido por idioma (ex: ru=yandex)"))
+	pflag.StringVar(&endpoint, "endpoint", "", T("URL do servidor do motor (ex: http://localhost:5000)"))
+	pflag.StringVar(&apiKey, "api-key", "", T("Chave de API do motor"))
+	pflag.StringVar(&formality, "formality", "", T("Formalidade do DeepL"))
+	pflag.StringSliceVar(&glossaries, "glossary", nil, T("Glossários do DeepL (id ou idioma=id)"))
+	pflag.StringVar(&model, "model", "", T("Modelo do motor llm/ollama"))
+	pflag.StringVar(&promptFile, "prompt-template", "", T("Template do prompt do motor llm/ollama"))
+	pflag.StringVar(&configFile, "config", configFile, T("Arquivo de configuração"))
+	pflag.StringVarP(&sourceLang, "source", "s", "auto", T("Idioma de origem"))
+	pflag.StringSliceVarP(&languages, "language", "l", nil, T("Idiomas destino"))
+	pflag.IntVarP(&jobs, "jobs", "j", 8, T("Traduções simultâneas"))
+	pflag.IntVar(&batchSize, "batch-size", 40, T("Segmentos por lote"))
+	pflag.IntVar(&batchChars, "batch-chars", 4500, T("Caracteres por lote"))
+	pflag.DurationVar(&callTimeout, "timeout", 0, T("Tempo máximo por chamada ao motor"))
+	pflag.Float64Var(&rateLimit, "rate-limit", 0, T("Requisições por segundo por motor"))
+	pflag.IntVar(&charsPerMinute, "chars-per-minute", 0, T("Caracteres por minuto por motor"))
+	pflag.IntVar(&retries, "retries", 3, T("Tentativas por motor"))
+	pflag.DurationVar(&backoffBase, "backoff", time.Second, T("Espera inicial entre tentativas"))
+	pflag.DurationVar(&backoffMax, "backoff-max", 30*time.Second, T("Espera máxima entre tentativas"))
+	pflag.IntVar(&breakerLimit, "breaker-threshold", 5, T("Falhas seguidas que pausam o motor"))
+	pflag.DurationVar(&breakerCooling, "breaker-cooldown", time.Minute, T("Tempo de pausa do motor"))
+	pflag.BoolVar(&markFuzzyFlag, "mark-fuzzy", false, T("Marca as traduções automáticas do PO como fuzzy, com a origem"))
+	pflag.StringVar(&moEndianness, "mo-endianness", "little", T("Ordem dos bytes dos .mo (little ou big)"))
+	pflag.BoolVarP(&forceFlag, "force", "f", false, T("Ignora o cache"))
+	pflag.BoolVar(&forceHumanFlag, "force-human", false, T("Ignora também as traduções humanas do cache"))
+	pflag.BoolVar(&cacheFallback, "cache-fallback", false, T("Aceita traduções do cache feitas por outros motores"))
+	pflag.BoolVar(&cleanCacheFlag, "clean-cache", false, T("Limpa cache antigo"))
+	pflag.StringVar(&cacheTTL, "cache-ttl", "", T("Remove do cache entradas sem uso há mais que isso"))
+	pflag.IntVar(&cacheMaxEntries, "cache-max-entries", 0, T("Máximo de entradas no cache"))
+	pflag.StringVar(&cacheMaxSize, "cache-max-size", "", T("Tamanho máximo do cache"))
+	pflag.StringVar(&cacheURL, "cache-url", "", T("Servidor de cache compartilhado"))
+	pflag.StringVar(&cacheToken, "cache-token", "", T("Token do servidor de cache"))
+	pflag.BoolVar(&selfFlag, "self", false, T("Extração especializada para o próprio chili-tradutor-go"))
+	pflag.BoolVar(&selfTestFlag, "self-test", false, T("Executa auto-teste de integridade"))
+	pflag.BoolVar(&resumeFlag, "resume", false, T("Continua uma execução interrompida"))
+	pflag.BoolVarP(&quietFlag, "quiet", "q", false, T("Modo silencioso"))
+	pflag.BoolVarP(&verboseFlag, "verbose", "v", false, T("Modo detalhado"))
+	pflag.BoolVarP(&versionFlag, "version", "V", false, T("Mostra versão"))
+	pflag.Parse()
+
+	loadConfig()
+	if err := resolveCachePolicy(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s %s\n", red(T("ERRO:")), white(err.Error()))
+		os.Exit(1)
+	}
+	setupRemoteCache()
+	registerCommandEngines()
+	if err := setupEngines(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s %s\n", red(T("ERRO:")), white(err.Error()))
+		os.Exit(1)
+	}
+	if moEndianness != "little" && moEndianness != "big" {
+		fmt.Fprintf(os.Stderr, "%s %s\n", red(T("ERRO:")), white(fmt.Sprintf(T("--mo-endianness deve ser little ou big: %s"), moEndianness)))
+		os.Exit(1)
+	}
+
+	targetLangs = defaultLanguages
+	if len(languages) > 0 {
+		if languages[0] == "all" {
+			targetLangs = supportedLanguages
+		} else {
+			targetLangs = languages
+		}
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	if retries < 1 {
+		retries = 1
+	}
+	if forceHumanFlag {
+		forceFlag = true
+	}
+	langPositions = make(map[string]int)
+	for i, lang := range targetLangs {
+		langPositions[lang] = len(targetLangs) - i
+	}
+}
+
+func setupEnvironment(ext, baseName, langName string) {
+	isMan, _ := regexp.MatchString(`^\.[1-9]$`, ext)
+	
+	if isMan {
+		os.MkdirAll("man", 0755)
+		return
+	}
+
+	switch ext {
+	case ".md", ".markdown":
+		os.MkdirAll("doc", 0755)
+	case ".txt":
+		os.MkdirAll("txt", 0755)
+	case ".json":
+		os.MkdirAll("json", 0755)
+	case ".yaml", ".yml":
+		os.MkdirAll("yml", 0755)
+	case ".html", ".htm":
+		os.MkdirAll("html", 0755)
+	default:
+		os.MkdirAll("pot", 0755)
+		targetPot := filepath.Join("pot", baseName)
+		if ext == ".pot" {
+			absInput, _ := filepath.Abs(currentFile)
+			absTarget, _ := filepath.Abs(targetPot)
+			if absInput != absTarget {
+				copyFile(currentFile, targetPot)
+			}
+		} else {
+			if selfFlag {
+				prepareGettextSelf(currentFile)
+			} else {
+				prepareGettext(currentFile, baseName, langName)
+			}
+		}
+	}
+}
+
+// --- FUNÇÕES DE TRADUÇÃO POR FORMATO ---
+
+func translateManPage(inputPath, lang string) {
+	content, _ := os.ReadFile(inputPath)
+	lines := strings.Split(string(content), "\n")
+	var translatedLines []string
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		// Protege macros roff (linhas que começam com ponto)
+		if strings.HasPrefix(trimmed, ".") {
+			parts := strings.Fields(line)
+			if len(parts) > 1 {
+				macro := parts[0]
+				rest := strings.Join(parts[1:], " ")
+				// Traduz apenas o que vem depois da macro, se não for comentário
+				if !strings.HasPrefix(rest, `\"`) {
+					translated := callUniversalTranslator(rest, lang)
+					translatedLines = append(translatedLines, macro+" "+translated)
+				} else {
+					translatedLines = append(translatedLines, line)
+				}
+			} else {
+				translatedLines = append(translatedLines, line)
+			}
+		} else if trimmed == "" {
+			translatedLines = append(translatedLines, line)
+		} else {
+			translated := callUniversalTranslator(line, lang)
+			translatedLines = append(translatedLines, translated)
+		}
+
+		if i%10 == 0 || i == len(lines)-1 {
+			updateProgress(lang, i+1, len(lines), "MAN")
+		}
+	}
+	
+	ext := filepath.Ext(inputPath)
+	base := strings.TrimSuffix(filepath.Base(inputPath), ext)
+	outFile := filepath.Join("man", fmt.Sprintf("%s-%s%s", base, lang, ext))
+	os.WriteFile(outFile, []byte(strings.Join(translatedLines, "\n")), 0644)
+	updateProgress(lang, len(lines), len(lines), "OK")
+}
+
+func translateHTML(inputPath, lang string) {
+	content, err := os.ReadFile(inputPath)
+	if err != nil { return }
+	lines := strings.Split(string(content), "\n")
+	var translatedLines []string
+	reTag := regexp.MustCompile(`(?s)<.*?>`)
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			translatedLines = append(translatedLines, line)
+			continue
+		}
+		tagMap := make(map[string]string)
+		counter := 0
+		protected := reTag.ReplaceAllStringFunc(line, func(tag string) string {
+			placeholder := fmt.Sprintf("CHILI_HTML_%d_CHILI", counter)
+			tagMap[placeholder] = tag
+			counter++
+			return placeholder
+		})
+		textOnly := reTag.ReplaceAllString(line, "")
 		if strings.TrimSpace(textOnly) != "" {
 			translated := callUniversalTranslator(protected, lang)
 			for placeholder, originalTag := range tagMap {
 				translated = strings.ReplaceAll(translated, placeholder, originalTag)
 			}
-			translatedLines = append(translatedLines, translated)
-		} else {
-			translatedLines = append(translatedLines, line)
+			translatedLines = append(translatedLines, translated)
+		} else {
+			translatedLines = append(translatedLines, line)
+		}
+		if i%5 == 0 || i == len(lines)-1 { updateProgress(lang, i+1, len(lines), "HTML") }
+	}
+	ext := filepath.Ext(inputPath)
+	base := strings.TrimSuffix(filepath.Base(inputPath), ext)
+	outFile := filepath.Join("html", fmt.Sprintf("%s-%s%s", base, lang, ext))
+	os.WriteFile(outFile, []byte(strings.Join(translatedLines, "\n")), 0644)
+	updateProgress(lang, len(lines), len(lines), "OK")
+}
+
+func translateMarkdown(inputPath, lang string) {
+	content, _ := os.ReadFile(inputPath)
+	lines := strings.Split(string(content), "\n")
+	translatedLines := make([]string, len(lines))
+	inCodeBlock := false
+	ext := filepath.Ext(inputPath)
+	base := strings.TrimSuffix(filepath.Base(inputPath), ext)
+	outFile := filepath.Join("doc", fmt.Sprintf("%s-%s%s", base, lang, ext))
+	rePrefix := regexp.MustCompile(`^(\s*#+\s*|\s*[\*\-\+]\s*|\s*\d+\.\s*)`)
+	var segments []string
+	var segLines []int
+	var prefixes []string
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		translatedLines[i] = line
+		if strings.HasPrefix(trimmed, "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock || trimmed == "" {
+			continue
+		}
+		prefix, textToTranslate := "", line
+		if loc := rePrefix.FindStringIndex(line); loc != nil {
+			prefix = line[loc[0]:loc[1]]
+			textToTranslate = line[loc[1]:]
+		}
+		segments = append(segments, textToTranslate)
+		segLines = append(segLines, i)
+		prefixes = append(prefixes, prefix)
+	}
+	translated, _ := translateSegments(segments, lang, func(done, total int) { updateProgress(lang, done, total, "MD") })
+	for j, i := range segLines {
+		translatedLines[i] = prefixes[j] + translated[j]
+	}
+	os.WriteFile(outFile, []byte(strings.Join(translatedLines, "\n")), 0644)
+	updateProgress(lang, len(lines), len(lines), "OK")
+}
+
+func translatePlaintext(inputPath, lang string) {
+	content, _ := os.ReadFile(inputPath)
+	lines := strings.Split(string(content), "\n")
+	ext := filepath.Ext(inputPath)
+	if ext == "" { ext = ".txt" }
+	base := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+	outFile := filepath.Join("txt", fmt.Sprintf("%s-%s%s", base, lang, ext))
+	translated, _ := translateSegments(lines, lang, func(done, total int) { updateProgress(lang, done, total, "TXT") })
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			translated[i] = line
+		}
+	}
+	os.WriteFile(outFile, []byte(strings.Join(translated, "\n")), 0644)
+	updateProgress(lang, len(lines), len(lines), "OK")
+}
+
+func translateFile(baseName, lang string) {
+	cleanBase := strings.TrimSuffix(baseName, filepath.Ext(baseName))
+	poTmp := filepath.Join("pot", fmt.Sprintf("%s-temp-%s.po", cleanBase, lang))
+	poFinal := filepath.Join("pot", fmt.Sprintf("%s-%s.po", cleanBase, lang))
+	po, err := readPOFile(poTmp)
+	if os.IsNotExist(err) {
+		return // xgettext não achou mensagens
+	}
+	if err != nil {
+		updateProgress(lang, 1, 1, "ERRO")
+		fmt.Printf("%s %s: %v\n", red(T("ERRO:")), poTmp, err)
+		return
+	}
+	po.Header = poHeader(lang)
+
+	// Como o msgmerge: o que já está traduzido no .po (inclusive o que os
+	// tradutores corrigiram à mão) é mantido e só o resto vai ao motor. Com
+	// --force o .po é refeito do zero; as traduções humanas voltam do cache.
+	forms := pluralFormsFor(lang)
+	if old, err := readPOFile(poFinal); err == nil {
+		if !forceFlag {
+			mergePO(po, old, forms.N)
+		}
+		if old.Header != nil {
+			fresh := (&poCatalog{Header: po.Header}).HeaderValue
+			po.Header = old.Header
+			for _, k := range []string{"Project-Id-Version", "POT-Creation-Date", "PO-Revision-Date", "Language", "Plural-Forms"} {
+				po.SetHeaderValue(k, fresh(k))
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		fmt.Printf("%s %s: %v\n", red(T("ERRO:")), poFinal, err)
+		return
+	}
+
+	// Coleta os textos para traduzi-los em lote; entradas com plural mandam
+	// um texto por forma do idioma, cada um com um número de exemplo.
+	var entries []*poEntry
+	var texts []string
+	for _, e := range po.Entries {
+		if e.Obsolete || e.translated() {
+			continue
+		}
+		entries = append(entries, e)
+		if e.Plural == "" {
+			texts = append(texts, e.ID)
+			continue
+		}
+		for _, src := range pluralSources(e, forms) {
+			texts = append(texts, src.text)
+		}
+	}
+	translations, origins := translateSegments(texts, lang, func(done, total int) { updateProgress(lang, done, total, "PO") })
+
+	// Mensagem que algum motor não traduziu fica sem msgstr, pendente.
+	i := 0
+	for _, e := range entries {
+		var strs []string
+		var from []segmentOrigin
+		restored := true
+		if e.Plural == "" {
+			strs, from = []string{keepSpaces(e.ID, translations[i])}, origins[i:i+1]
+			i++
+		} else {
+			srcs := pluralSources(e, forms)
+			for j, src := range srcs {
+				str, ok := src.restore(keepSpaces(src.text, translations[i+j]))
+				strs = append(strs, str)
+				restored = restored && (ok || str == "")
+			}
+			from = origins[i : i+len(srcs)]
+			i += len(srcs)
+		}
+		if !segmentsTranslated(texts[i-len(from):i], from) {
+			continue
+		}
+		e.Str = strs
+		if markFuzzyFlag {
+			markMachineTranslation(e, from)
+		}
+		// Sem o número de exemplo não dá para recolocar o %d: a forma fica
+		// como o motor devolveu, mas fuzzy, para alguém revisar.
+		if !restored {
+			e.setFlag("fuzzy", true)
+		}
+	}
+	if err := writePOFile(poFinal, po); err != nil {
+		fmt.Printf("%s %s: %v\n", red(T("ERRO:")), poFinal, err)
+		return
+	}
+	os.Remove(poTmp)
+	updateProgress(lang, 1, 1, "OK")
+}
+
+func segmentsTranslated(texts []string, origins []segmentOrigin) bool {
+	for i, o := range origins {
+		if o.Engine == "" && strings.TrimSpace(texts[i]) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+const provenancePrefix = "# chili-tradutor-go: "
+
+// markMachineTranslation marca como fuzzy a mensagem traduzida por motor e
+// anota num comentário do tradutor o motor, a data e se a tradução saiu do
+// cache ou foi feita agora. Traduções humanas do cache só levam o comentário.
+func markMachineTranslation(e *poEntry, origins []segmentOrigin) {
+	var engines []string
+	cached, fresh, human := false, false, true
+	for _, o := range origins {
+		if o.Engine == "" {
+			continue
+		}
+		if !containsString(engines, o.Engine) {
+			engines = append(engines, o.Engine)
+		}
+		if o.Cached {
+			cached = true
+		} else {
+			fresh = true
+		}
+		human = human && o.Engine == humanEngine
+	}
+	if len(engines) == 0 {
+		return
+	}
+	how := "fresh"
+	switch {
+	case cached && fresh:
+		how = "cache+fresh"
+	case cached:
+		how = "cache"
+	}
+	var comments []string
+	for _, c := range e.Comments {
+		if !strings.HasPrefix(c, provenancePrefix) {
+			comments = append(comments, c)
+		}
+	}
+	note := provenancePrefix + strings.Join(engines, "+") + ", " + time.Now().Format("2006-01-02") + ", " + how
+	if e.flagsAt > 0 {
+		e.flagsAt += 1 + len(comments) - len(e.Comments)
+	}
+	e.Comments = append([]string{note}, comments...)
+	e.setFlag("fuzzy", !human)
+}
+
+// keepSpaces devolve a tradução com os espaços e quebras de linha do começo
+// e do fim do original, que os motores descartam. Sem tradução devolve "",
+// para que a mensagem fique pendente e seja tentada de novo na próxima vez.
+func keepSpaces(src, translated string) string {
+	core := strings.TrimSpace(src)
+	translated = strings.TrimSpace(translated)
+	if core == "" {
+		return src
+	}
+	if translated == "" {
+		return ""
+	}
+	lead := src[:strings.Index(src, core)]
+	return lead + translated + src[len(lead)+len(core):]
+}
+
+func translateJSON(path, lang string) {
+	ext := strings.ToLower(filepath.Ext(path))
+	targetDir := "json"
+	if ext == ".yaml" || ext == ".yml" { targetDir = "yml" }
+	data, _ := os.ReadFile(path)
+	var obj map[string]interface{}
+	json.Unmarshal(data, &obj)
+	translateMap(obj, lang)
+	out, _ := json.MarshalIndent(obj, "", "  ")
+	outFile := filepath.Join(targetDir, fmt.Sprintf("%s-%s%s", strings.TrimSuffix(filepath.Base(path), ext), lang, ext))
+	os.WriteFile(outFile, out, 0644)
+	updateProgress(lang, 100, 100, strings.ToUpper(targetDir))
+}
+
+func translateMap(m map[string]interface{}, lang string) {
+	for k, v := range m {
+		if val, ok := v.(string); ok {
+			m[k] = callUniversalTranslator(val, lang)
+		} else if valMap, ok := v.(map[string]interface{}); ok {
+			translateMap(valMap, lang)
+		}
+	}
+}
+
+// --- UTILITÁRIOS ---
+
+func updateProgress(lang string, current, total int, suffix string) {
+	if quietFlag || total == 0 { return }
+	muConsole.Lock()
+	defer muConsole.Unlock()
+	pos := langPositions[lang]
+	if pos == 0 { return }
+	percent := (current * 100) / total
+	width := 40
+	filled := (percent * width) / 100
+	bar := blue(strings.Repeat("░", filled)) + strings.Repeat(" ", width-filled)
+	langStr := fmt.Sprintf("%-7s", lang)
+	fmt.Printf("\033[%dA\r\033[K    → %s %s [%s] %3d%% %-5s\033[%dB", pos, blue("→"), cyan(langStr), bar, percent, cyan(suffix), pos)
+}
+
+func prepareMsginit(base, lang string) {
+	cleanBase := strings.TrimSuffix(base, filepath.Ext(base))
+	pot := filepath.Join("pot", cleanBase+".pot")
+	po := filepath.Join("pot", fmt.Sprintf("%s-temp-%s.po", cleanBase, lang))
+	os.Remove(po)
+	execCommand("msginit", "--no-translator", "-l", lang, "-i", pot, "-o", po).Run()
+}
+
+func protectVariables(text string) (string, map[string]string) {
+	re := regexp.MustCompile(`(\$\{[A-Za-z0-9_.]+\}|\$[A-Za-z0-9_.]+|%[a-z]|!\[.*?\]\(.*?\)|\[.*?\]\(.*?\)|https?://[^\s]+)`)
+	placeholders := make(map[string]string)
+	protected := text
+	matches := re.FindAllString(text, -1)
+	for i, match := range matches {
+		p := fmt.Sprintf("CHILI_REF_%d_CHILI", i)
+		placeholders[p] = match
+		protected = strings.Replace(protected, match, p, 1)
+	}
+	return protected, placeholders
+}
+
+func restoreVariables(text string, p map[string]string) string {
+	for k, v := range p { text = strings.Replace(text, k, v, -1) }
+	return text
+}
+
+func detectFileType(path string) (ext string, lang string, desc string) {
+	ext = strings.ToLower(filepath.Ext(path))
+	
+	// Caso 1: Arquivo sem extensão
+	if ext == "" {
+		detected, _ := getShebangInfo(path)
+		if detected != "" {
+			return "", detected, fmt.Sprintf(T("Script (%s)"), green(detected))
+		}
+		return ".txt", "text", T("Texto Simples (sem extensão)")
+	}
+
+	// Caso 2: Man Pages ( .1 a .9 )
+	isMan, _ := regexp.MatchString(`^\.[1-9]$`, ext)
+	if isMan {
+		return ext, "manpage", fmt.Sprintf(T("Manual do Linux (%s)"), cyan(ext))
+	}
+
+	extMap := map[string]string{
+		".sh": "shell", ".py": "python", ".php": "php", ".c": "c",
+		".cpp": "c++", ".go": "go", ".pl": "perl", ".rb": "ruby",
+		".html": "html", ".htm": "html",
+	}
+
+	if l, ok := extMap[ext]; ok {
+		return ext, l, fmt.Sprintf(T("Código %s (%s)"), ext, green(l))
+	}
+
+	switch ext {
+	case ".md", ".markdown": return ext, "markdown", T("Markdown")
+	case ".txt": return ext, "text", T("Texto Simples")
+	case ".json": return ext, "json", T("JSON")
+	case ".yaml", ".yml": return ext, "yaml", T("YAML")
+	case ".pot": return ext, "gettext", T("Template POT")
+	}
+
+	return ext, "shell", fmt.Sprintf(T("Arquivo %s"), ext)
+}
+
+func getShebangInfo(path string) (string, string) {
+	f, err := os.Open(path)
+	if err != nil { return "", "" }
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	if scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#!") {
+			lower := strings.ToLower(line)
+			switch {
+			case strings.Contains(lower, "python"): return "python", line
+			case strings.Contains(lower, "php"): return "php", line
+			case strings.Contains(lower, "perl"): return "perl", line
+			case strings.Contains(lower, "ruby"): return "ruby", line
+			case strings.Contains(lower, "node"): return "javascript", line
+			case strings.Contains(lower, "bash") || strings.Contains(lower, "sh"): return "shell", line
+			}
+			return "shell", line
+		}
+	}
+	return "", ""
+}
+
+func checkInternet() bool {
+	conn, err := net.DialTimeout("tcp", "8.8.8.8:53", 2*time.Second)
+	if err != nil { return false }
+	conn.Close()
+	return true
+}
+
+func detectDistro() string {
+	osData, _ := os.ReadFile("/etc/os-release")
+	osContent := string(osData)
+	re := regexp.MustCompile(`(?m)^ID=["']?([^"'\s]+)["']?`)
+	match := re.FindStringSubmatch(osContent)
+	if len(match) > 1 { return strings.ToLower(match[1]) }
+	return "unknown"
+}
+
+func checkDependencies() {
+	deps := map[string]string{
+		"xgettext": "gettext", "msginit":  "gettext",
+		"gettext":  "gettext", "ngettext": "gettext",
+	}
+	for _, e := range engineInstances {
+		if _, ok := e.(*transShellEngine); ok {
+			deps["trans"] = "translate-shell"
+		}
+	}
+	missingMap := make(map[string]bool)
+	hasMissing := false
+	for bin, pkg := range deps {
+		if _, err := exec.LookPath(bin); err != nil {
+			missingMap[pkg] = true
+			hasMissing = true
+		}
+	}
+	if !hasMissing { return }
+	var missingPkgs []string
+	for pkg := range missingMap { missingPkgs = append(missingPkgs, pkg) }
+	pkgList := strings.Join(missingPkgs, " ")
+	muConsole.Lock()
+	fmt.Printf("\n%s %s\n", red(" [ERRO]"), white(T("Dependências ausentes: ")+pkgList))
+	distro := detectDistro()
+	installCmd := ""
+	switch distro {
+	case "chili", "chililinux", "arch": installCmd = "sudo pacman -S " + pkgList
+	case "void": installCmd = "sudo xbps-install -S " + pkgList
+	case "debian", "ubuntu": installCmd = "sudo apt install " + pkgList
+	case "fedora": installCmd = "sudo dnf install " + pkgList
+	}
+	if installCmd != "" {
+		fmt.Printf("\n %s %s (%s)? (s/N): ", yellow(" →"), T("Deseja instalar automaticamente para"), cyan(distro))
+		muConsole.Unlock()
+		var response string
+		fmt.Scanln(&response)
+		if strings.ToLower(response) == "s" {
+			args := strings.Fields(installCmd)
+			cmd := exec.Command(args[0], args[1:]...)
+			cmd.Stdout, cmd.Stderr, cmd.Stdin = os.Stdout, os.Stderr, os.Stdin
+			if err := cmd.Run(); err == nil { return }
+		}
+	} else { muConsole.Unlock() }
+	os.Exit(1)
+}
+
+func loadConfig() {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s %s: %v\n", yellow(T("[AVISO]")), white(T("Configuração inválida ignorada")), err)
+		config = Config{}
+	}
+}
+
+// --- ARMAZENAMENTO DO CACHE ---
+
+// O cache fica num banco SQLite (cache.db), numa tabela com chave primária
+// (idioma, chave): cada consulta do caminho de tradução é uma busca pelo
+// índice e cada tradução nova é gravada assim que chega, sem ler nem
+// regravar o resto. Só os subcomandos que percorrem o cache inteiro (stats,
+// search, export...) carregam as entradas em cacheData. O último uso
+// (LastUsed) das entradas consultadas é gravado em lote no saveCache. No
+// modo WAL várias execuções (make -j) leem e gravam o mesmo arquivo ao mesmo
+// tempo; o próprio SQLite serializa as gravações.
+
+// cacheSchemaVersion vai no user_version do banco; as chaves seguem o
+// esquema 2 (ver cacheKey).
+const cacheSchemaVersion = 2
+
+// cacheFormat identifica o cache nos TMX exportados (o-tmf).
+const cacheFormat = "chili-tradutor-go-cache"
+
+const cacheSchema = `
+CREATE TABLE IF NOT EXISTS entries (
+	lang      TEXT NOT NULL,
+	key       TEXT NOT NULL,
+	value     TEXT NOT NULL,
+	last_used INTEGER NOT NULL,
+	source    TEXT NOT NULL DEFAULT '',
+	from_lang TEXT NOT NULL DEFAULT '',
+	engine    TEXT NOT NULL DEFAULT '',
+	human     INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (lang, key)
+) WITHOUT ROWID;
+CREATE INDEX IF NOT EXISTS entries_by_text ON entries (lang, from_lang, source);
+`
+
+const cacheColumns = "lang, key, value, last_used, source, from_lang, engine, human"
+
+// openCacheDB abre (e, para gravação, cria) um banco de cache. Uma só
+// conexão por processo: as goroutines de tradução já passam por mu.
+func openCacheDB(path string, readOnly bool) (*sql.DB, error) {
+	dsn := "file:" + path + "?_pragma=busy_timeout(10000)"
+	if readOnly {
+		// Com mode=ro o SQLite cria o -wal e o -shm mas não os apaga ao
+		// fechar; query_only lê do mesmo jeito e não deixa restos.
+		dsn += "&mode=rw&_pragma=query_only(1)"
+	} else {
+		dsn += "&_pragma=auto_vacuum(incremental)&_pragma=journal_mode(wal)&_pragma=synchronous(normal)&_txlock=immediate"
+	}
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if version > cacheSchemaVersion {
+		db.Close()
+		return nil, fmt.Errorf(T("%s: esquema %d é mais novo que o desta versão (%d)"), path, version, cacheSchemaVersion)
+	}
+	if !readOnly && version < cacheSchemaVersion {
+		if _, err := db.Exec(cacheSchema + fmt.Sprintf("PRAGMA user_version = %d;", cacheSchemaVersion)); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// sqlRunner é o que *sql.DB e *sql.Tx têm em comum.
+type sqlRunner interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// cacheStorage é o banco aberto pelo processo; db é nil quando o cache não
+// pôde ser aberto, e então a execução segue sem cache. Os métodos supõem
+// que o chamador segura mu (ou que não há goroutines de tradução rodando).
+type cacheStorage struct {
+	db      *sql.DB
+	tx      *sql.Tx
+	lock    *os.File
+	touched map[string]map[string]time.Time
+	failed  bool
+	warn    sync.Once
+}
+
+var storage cacheStorage
+
+func (s *cacheStorage) runner() sqlRunner {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+func (s *cacheStorage) fail(err error) {
+	s.failed = true
+	s.warn.Do(func() {
+		fmt.Fprintf(os.Stderr, "%s %s: %v\n", yellow(T("[AVISO]")), white(T("Falha ao gravar o cache")), err)
+	})
+}
+
+// batch roda fn numa transação: as gravações feitas dentro dela chegam ao
+// disco de uma vez.
+func (s *cacheStorage) batch(fn func()) {
+	if s.db == nil || s.tx != nil {
+		fn()
+		return
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.fail(err)
+		fn()
+		return
+	}
+	s.tx = tx
+	fn()
+	s.tx = nil
+	if err := tx.Commit(); err != nil {
+		s.fail(err)
+	}
+}
+
+func scanCacheEntry(row interface{ Scan(...any) error }) (string, string, CacheEntry, error) {
+	var lang, key string
+	var e CacheEntry
+	var used int64
+	err := row.Scan(&lang, &key, &e.Value, &used, &e.Source, &e.From, &e.Engine, &e.Human)
+	e.LastUsed = time.UnixMilli(used)
+	return lang, key, e, err
+}
+
+func (s *cacheStorage) get(lang, key string) (CacheEntry, bool) {
+	if s.db == nil {
+		return CacheEntry{}, false
+	}
+	_, _, e, err := scanCacheEntry(s.runner().QueryRow("SELECT "+cacheColumns+" FROM entries WHERE lang = ? AND key = ?", lang, key))
+	return e, err == nil
+}
+
+// findText atende o --cache-fallback: a tradução de qualquer motor para o
+// mesmo texto e origem, pelo índice entries_by_text.
+func (s *cacheStorage) findText(lang, from, source string) (string, CacheEntry, bool) {
+	if s.db == nil {
+		return "", CacheEntry{}, false
+	}
+	row := s.runner().QueryRow("SELECT "+cacheColumns+" FROM entries WHERE lang = ? AND from_lang = ? AND source = ? "+
+		"ORDER BY human DESC, last_used DESC LIMIT 1", lang, from, source)
+	_, key, e, err := scanCacheEntry(row)
+	return key, e, err == nil
+}
+
+func (s *cacheStorage) put(lang, key string, e CacheEntry) {
+	if s.db == nil {
+		return
+	}
+	_, err := s.runner().Exec("INSERT OR REPLACE INTO entries ("+cacheColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		lang, key, e.Value, e.LastUsed.UnixMilli(), e.Source, e.From, e.Engine, e.Human)
+	if err != nil {
+		s.fail(err)
+	}
+}
+
+func (s *cacheStorage) del(lang, key string) {
+	if s.db == nil {
+		return
+	}
+	if _, err := s.runner().Exec("DELETE FROM entries WHERE lang = ? AND key = ?", lang, key); err != nil {
+		s.fail(err)
+	}
+}
+
+// touch anota o último uso; a gravação fica para o saveCache.
+func (s *cacheStorage) touch(lang, key string, t time.Time) {
+	if s.touched == nil {
+		s.touched = make(map[string]map[string]time.Time)
+	}
+	if s.touched[lang] == nil {
+		s.touched[lang] = make(map[string]time.Time)
+	}
+	s.touched[lang][key] = t
+}
+
+func (s *cacheStorage) count() int {
+	n := 0
+	if s.db != nil {
+		s.runner().QueryRow("SELECT COUNT(*) FROM entries").Scan(&n)
+	}
+	return n
+}
+
+// all carrega o cache inteiro, para os subcomandos que o percorrem.
+func (s *cacheStorage) all() map[string]map[string]CacheEntry {
+	if s.db == nil {
+		return make(map[string]map[string]CacheEntry)
+	}
+	data, err := readCacheRows(s.runner())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %s: %v\n", red(T("ERRO:")), white(T("Falha ao ler o cache")), err)
+	}
+	return data
+}
+
+// readCacheRows lê todas as entradas; num banco danificado devolve as que
+// vieram antes do erro.
+func readCacheRows(db sqlRunner) (map[string]map[string]CacheEntry, error) {
+	data := make(map[string]map[string]CacheEntry)
+	rows, err := db.Query("SELECT " + cacheColumns + " FROM entries")
+	if err != nil {
+		return data, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		lang, key, e, err := scanCacheEntry(rows)
+		if err != nil {
+			return data, err
+		}
+		if data[lang] == nil {
+			data[lang] = make(map[string]CacheEntry)
+		}
+		data[lang][key] = e
+	}
+	return data, rows.Err()
+}
+
+// Migração e reparo trocam o cache.db inteiro e exigem a trava exclusiva em
+// cache.db.lock; cada processo que usa o cache segura a compartilhada
+// enquanto roda.
+func lockCache(how int) (unlock func(), err error) {
+	if storage.lock == nil {
+		f, err := os.OpenFile(cacheFile+".lock", os.O_CREATE|os.O_RDWR, 0644)
+		if err != nil {
+			return func() {}, err
+		}
+		storage.lock = f
+	}
+	fd := int(storage.lock.Fd())
+	if err := syscall.Flock(fd, how); err != nil {
+		return func() {}, err
+	}
+	return func() { syscall.Flock(fd, syscall.LOCK_UN) }, nil
+}
+
+func loadCache() {
+	if storage.db != nil {
+		return
+	}
+	lockCache(syscall.LOCK_EX)
+	if _, err := os.Stat(cacheFile); os.IsNotExist(err) {
+		migrateLegacyCache()
+	}
+	db, err := openCacheDB(cacheFile, false)
+	// A exclusiva vira compartilhada: outras execuções podem abrir o cache,
+	// mas nenhuma o substitui enquanto esta roda.
+	lockCache(syscall.LOCK_SH)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %s: %v. %s\n", red(T("ERRO:")), white(T("Falha ao abrir o cache")), err,
+			white(fmt.Sprintf(T("Seguindo sem cache; use '%s cache verify'."), _APP_)))
+		return
+	}
+	storage.db = db
+}
+
+// loadCacheEntries abre o cache e o carrega em cacheData, para os
+// subcomandos que o percorrem e alteram.
+func loadCacheEntries() {
+	loadCache()
+	cacheData = storage.all()
+}
+
+// readCacheEntries carrega o cache em cacheData só para leitura, para os
+// subcomandos de consulta: não cria o cache.db, não migra o cache.json e
+// não toma a trava. Sem cache.db, lê o cache.json como está. Devolve o
+// arquivo lido.
+func readCacheEntries() (string, bool) {
+	path := cacheFile
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		path = legacyCache
+	}
+	data, err := readCacheFile(path)
+	switch {
+	case os.IsNotExist(err):
+		data = make(map[string]map[string]CacheEntry)
+	case err != nil && data == nil:
+		fmt.Fprintf(os.Stderr, "%s %s: %v\n", red(T("ERRO:")), white(T("Falha ao ler o cache")), err)
+		return path, false
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "%s %s: %v. %s\n", yellow(T("[AVISO]")), white(T("Cache lido em parte")), err,
+			white(fmt.Sprintf(T("Use '%s cache verify'."), _APP_)))
+	}
+	cacheData = data
+	return path, true
+}
+
+// migrateLegacyCache converte, uma única vez, o antigo cache.json
+// (map[string]map[string]CacheEntry) para o banco.
+func migrateLegacyCache() {
+	data, err := os.ReadFile(legacyCache)
+	if err != nil {
+		return
+	}
+	legacy := make(map[string]map[string]CacheEntry)
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		fmt.Fprintf(os.Stderr, "%s %s: %v. %s\n", yellow(T("[AVISO]")), white(T("cache.json antigo ilegível; migração ignorada")), err,
+			white(fmt.Sprintf(T("Use '%s cache verify --repair' para recuperar o que for possível."), _APP_)))
+		return
+	}
+	if err := writeCacheSnapshot(cacheFile, upgradeCacheV1(legacy)); err != nil {
+		fmt.Fprintf(os.Stderr, "%s %s: %v\n", red(T("ERRO:")), white(T("Falha ao migrar o cache")), err)
+		return
+	}
+	os.Rename(legacyCache, legacyCache+".migrated")
+	fmt.Printf("%s %s %s\n", green("✔"), white(T("Cache migrado para")), cyan(cacheFile))
+}
+
+// upgradeCacheV1 converte o esquema 1 (cacheData[idioma][texto em minúsculas])
+// para o esquema 2. Como o esquema 1 não guardava motor nem origem, as
+// entradas viram "legacy|auto|texto" sem Source: o texto original, com suas
+// maiúsculas, se perdeu. localCacheLookup as consulta sem diferenciar
+// maiúsculas.
+func upgradeCacheV1(old map[string]map[string]CacheEntry) map[string]map[string]CacheEntry {
+	upgraded := make(map[string]map[string]CacheEntry)
+	for lang, entries := range old {
+		if upgraded[lang] == nil {
+			upgraded[lang] = make(map[string]CacheEntry)
+		}
+		for text, e := range entries {
+			if e.Engine != "" {
+				upgraded[lang][text] = e
+				continue
+			}
+			e.Source, e.From, e.Engine = "", "auto", "legacy"
+			upgraded[lang][legacyCacheKey(text)] = e
+		}
+	}
+	return upgraded
+}
+
+// writeCacheSnapshot grava um banco novo com as entradas num temporário do
+// mesmo diretório e o põe no lugar de path.
+func writeCacheSnapshot(path string, data map[string]map[string]CacheEntry) error {
+	tmp := fmt.Sprintf("%s.tmp-%d", path, os.Getpid())
+	os.Remove(tmp)
+	defer os.Remove(tmp)
+	db, err := openCacheDB(tmp, false)
+	if err != nil {
+		return err
+	}
+	snapshot := cacheStorage{db: db}
+	snapshot.batch(func() {
+		for lang, entries := range data {
+			for key, e := range entries {
+				snapshot.put(lang, key, e)
+			}
+		}
+	})
+	_, err = db.Exec("PRAGMA journal_mode = delete")
+	if closeErr := db.Close(); err != nil || closeErr != nil || snapshot.failed {
+		return fmt.Errorf(T("%s: falha ao gravar"), tmp)
+	}
+	// Restos do modo WAL do arquivo antigo não valem para o novo.
+	os.Remove(path + "-wal")
+	os.Remove(path + "-shm")
+	return os.Rename(tmp, path)
+}
+
+func saveCache() {
+	mu.Lock()
+	defer mu.Unlock()
+	if storage.db == nil {
+		return
+	}
+	storage.batch(func() {
+		for lang, keys := range storage.touched {
+			for key, t := range keys {
+				if _, err := storage.runner().Exec("UPDATE entries SET last_used = ? WHERE lang = ? AND key = ? AND last_used < ?",
+					t.UnixMilli(), lang, key, t.UnixMilli()); err != nil {
+					storage.fail(err)
+				}
+			}
+		}
+	})
+	storage.touched = nil
+	if evictPolicy.active() {
+		applyEviction(planEviction(storage.all(), evictPolicy, time.Now()))
+	}
+}
+
+// closeCache fecha o banco; o SQLite incorpora o WAL ao cache.db.
+func closeCache() {
+	if storage.db != nil {
+		storage.db.Close()
+		storage.db = nil
+	}
+}
+
+const cacheBackups = 3
+
+// backupCache gira os backups e copia o cache atual para cache.db.bak.1. O
+// VACUUM INTO inclui o que ainda está no WAL; num banco danificado, que o
+// SQLite não consegue ler, copia o arquivo como está.
+func backupCache() error {
+	if _, err := os.Stat(cacheFile); os.IsNotExist(err) {
+		return nil
+	}
+	for i := cacheBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.bak.%d", cacheFile, i), fmt.Sprintf("%s.bak.%d", cacheFile, i+1))
+	}
+	backup := cacheFile + ".bak.1"
+	os.Remove(backup)
+	if db, err := openCacheDB(cacheFile, true); err == nil {
+		_, err = db.Exec("VACUUM INTO ?", backup)
+		db.Close()
+		if err == nil {
+			return nil
+		}
+	}
+	current, err := os.ReadFile(cacheFile)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(backup, current, 0644)
+}
+
+// writeFileAtomic grava num temporário do mesmo diretório e renomeia, para que
+// uma interrupção no meio nunca deixe o arquivo pela metade.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// --- RETOMADA DE EXECUÇÕES INTERROMPIDAS ---
+
+// runState registra os pares arquivo/idioma concluídos. O arquivo é
+// identificado pelo caminho absoluto, tamanho e data de modificação; se
+// mudar, o progresso anterior deixa de valer.
+type runState struct {
+	Files map[string]*fileState `json:"files"`
+}
+
+type fileState struct {
+	Size    int64                `json:"size"`
+	ModTime time.Time            `json:"mtime"`
+	Langs   map[string]time.Time `json:"langs"`
+}
+
+var (
+	state   = runState{Files: make(map[string]*fileState)}
+	muState sync.Mutex
+)
+
+func loadRunState() {
+	data, err := os.ReadFile(stateFile)
+	if err != nil {
+		return
+	}
+	var loaded runState
+	if json.Unmarshal(data, &loaded) == nil && loaded.Files != nil {
+		state = loaded
+	}
+}
+
+func fileStateFor(path string, create bool) *fileState {
+	abs, _ := filepath.Abs(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	fs, ok := state.Files[abs]
+	if ok && fs.Size == info.Size() && fs.ModTime.Equal(info.ModTime()) {
+		return fs
+	}
+	if !create {
+		return nil
+	}
+	fs = &fileState{Size: info.Size(), ModTime: info.ModTime(), Langs: make(map[string]time.Time)}
+	state.Files[abs] = fs
+	return fs
+}
+
+func isLangDone(path, lang string) bool {
+	muState.Lock()
+	defer muState.Unlock()
+	fs := fileStateFor(path, false)
+	if fs == nil {
+		return false
+	}
+	_, done := fs.Langs[lang]
+	return done
+}
+
+func markLangDone(path, lang string) {
+	muState.Lock()
+	defer muState.Unlock()
+	fs := fileStateFor(path, true)
+	if fs == nil {
+		return
+	}
+	fs.Langs[lang] = time.Now()
+	data, _ := json.MarshalIndent(state, "", "  ")
+	writeFileAtomic(stateFile, data, 0644)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func copyFile(src, dst string) error {
+	s, _ := os.Open(src); defer s.Close()
+	d, _ := os.Create(dst); defer d.Close()
+	_, err := io.Copy(d, s)
+	return err
+}
+
+func hasActualContent(ext, baseName string) bool {
+	if selfFlag { return true }
+	isMan, _ := regexp.MatchString(`^\.[1-9]$`, ext)
+	if isMan { return true }
+	if ext == ".md" || ext == ".markdown" || ext == ".txt" || ext == ".json" || ext == ".yaml" || ext == ".yml" || ext == ".html" || ext == ".htm" { return true }
+	potFile := filepath.Join("pot", baseName+".pot")
+	if _, err := os.Stat(potFile); err == nil {
+		content, _ := os.ReadFile(potFile)
+		return strings.Contains(string(content), "msgid")
+	}
+	return true
+}
+
+func cleanupEmpty(ext, baseName string) {
+	potFile := filepath.Join("pot", baseName+".pot")
+	os.Remove(potFile)
+}
+
+func printWelcome(desc string) {
+	fmt.Printf("\n%s %s %s\n", cyan(">>"), white(_APP_), white(_VERSION_))
+	fmt.Printf("%s %s\n", yellow(T("[STEP 1]")), white(T("Ambiente preparado com sucesso.")))
+	fmt.Printf("    → %-15s: %s\n", T("Arquivo"), white(currentFile))
+	fmt.Printf("    → %-15s: %s\n", T("Tipo"), cyan(desc))
+	fmt.Printf("    → %-15s: %s\n", T("Motor"), green(engine))
+	if len(engineRoutes) > 0 {
+		var list []string
+		for _, lang := range sortedKeys(engineRoutes) {
+			list = append(list, lang+"="+engineRoutes[lang].Name())
+		}
+		fmt.Printf("    → %-15s: %s\n", T("Rotas"), green(strings.Join(list, ", ")))
+	}
+	fmt.Printf("    → %-15s: %s (%s)\n", T("Origem"), green(sourceLang), T("Auto-detect se auto"))
+	fmt.Printf("    → %-15s: %s\n", T("Jobs"), red(jobs))
+	fmt.Printf("    → %-15s: %s\n\n", T("Cache"), blue(cacheFile))
+}
+
+func showQuickStats(start time.Time) {
+	total := cacheHits + netCalls
+	pCache, pNet := 0.0, 0.0
+	if total > 0 {
+		pCache = (float64(cacheHits) / float64(total)) * 100
+		pNet = (float64(netCalls) / float64(total)) * 100
+	}
+	fmt.Printf("\n\n%s %s em %v | %s %d (%.2f%%) | %s %d (%.2f%%) | %s %d\n", green("✔"), white(T("Concluído")), time.Since(start).Round(time.Second), blue(T("Cache:")), cacheHits, pCache, yellow(T("Net:")), netCalls, pNet, white(T("Total:")), total)
+	showFailureReport()
+}
+
+func showFinalSummary(start time.Time) {
+	fmt.Printf("%s\n %s\n", white(strings.Repeat("-", 60)), yellow(T("RESUMO EXECUTIVO FINAL:")))
+	fmt.Printf("    → %-15s: %v\n", T("Tempo Total"), time.Since(start).Round(time.Second))
+	fmt.Printf("    → %-15s: %d\n", T("Cache Hits"), cacheHits)
+	fmt.Printf("    → %-15s: %d\n", T("Chamadas Rede"), netCalls)
+	if atomic.LoadInt32(&failedCalls) > 0 {
+		fmt.Printf("    → %-15s: %s\n", T("Falhas"), red(atomic.LoadInt32(&failedCalls)))
+	}
+	showEngineSummary()
+	fmt.Printf("%s\n\n", white(strings.Repeat("-", 60)))
+}
+
+// showEngineSummary mostra quantos segmentos cada motor traduziu e quantas
+// vezes o disjuntor o pausou.
+func showEngineSummary() {
+	for _, name := range sortedKeys(engineServed) {
+		fmt.Printf("    → %-15s: %d %s\n", fmt.Sprintf(T("Motor %s"), name), engineServed[name], T("segmentos"))
+	}
+	for _, name := range sortedKeys(engineGuards) {
+		if trips := engineGuards[name].breaker.trips; trips > 0 {
+			fmt.Printf("    → %-15s: %s %s\n", fmt.Sprintf(T("Motor %s"), name), red(trips), T("pausa(s) por falhas seguidas"))
+		}
+	}
+}
+
+func doCleanCache() {
+	policy := evictPolicy
+	if policy.TTL == 0 {
+		policy.TTL = 30 * 24 * time.Hour
+	}
+	plan := planEviction(storage.all(), policy, time.Now())
+	applyEviction(plan)
+	fmt.Printf("%s %s %d %s\n", green("✔"), T("Removidos"), len(plan), T("itens obsoletos do cache."))
+}
+
+// --- POLÍTICA DE EXPURGO DO CACHE ---
+
+// cachePolicy decide o que sai do cache ao salvar: entradas sem uso há mais
+// de TTL e, acima dos limites de entradas ou de bytes, as de uso mais antigo.
+type cachePolicy struct {
+	TTL        time.Duration
+	MaxEntries int
+	MaxBytes   int64
+}
+
+func (p cachePolicy) active() bool {
+	return p.TTL > 0 || p.MaxEntries > 0 || p.MaxBytes > 0
+}
+
+var (
+	cacheTTL        string
+	cacheMaxSize    string
+	cacheMaxEntries int
+	evictPolicy     cachePolicy
+	cacheURL        string
+	cacheToken      string
+)
+
+// resolveCachePolicy junta as flags e a seção "cache" da configuração; a
+// flag prevalece quando informada.
+func resolveCachePolicy() error {
+	ttl, err := parseAge(firstNonEmpty(cacheTTL, config.Cache.TTL))
+	if err != nil {
+		return fmt.Errorf("%s: %v", T("TTL do cache inválido"), err)
+	}
+	size, err := parseByteSize(firstNonEmpty(cacheMaxSize, config.Cache.MaxSize))
+	if err != nil {
+		return fmt.Errorf("%s: %v", T("Tamanho máximo do cache inválido"), err)
+	}
+	entries := cacheMaxEntries
+	if entries == 0 {
+		entries = config.Cache.MaxEntries
+	}
+	evictPolicy = cachePolicy{TTL: ttl, MaxEntries: entries, MaxBytes: size}
+	return nil
+}
+
+// parseAge aceita durações do Go e também dias ("30d").
+func parseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n float64
+		if _, err := fmt.Sscanf(days, "%g", &n); err != nil || n < 0 {
+			return 0, fmt.Errorf("%q", s)
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err == nil && d < 0 {
+		err = fmt.Errorf("%q", s)
+	}
+	return d, err
+}
+
+// parseByteSize aceita bytes ou os sufixos K, M e G (com ou sem "B"/"iB").
+func parseByteSize(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	units := []struct {
+		suffix string
+		mult   int64
+	}{{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"", 1}}
+	num := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(s)), "B"), "I")
+	for _, u := range units {
+		if rest, ok := strings.CutSuffix(num, u.suffix); ok {
+			var n float64
+			if _, err := fmt.Sscanf(rest, "%g", &n); err != nil || n < 0 {
+				return 0, fmt.Errorf("%q", s)
+			}
+			return int64(n * float64(u.mult)), nil
+		}
+	}
+	return 0, fmt.Errorf("%q", s)
+}
+
+type evictItem struct {
+	lang, key string
+	entry     CacheEntry
+	expired   bool
+}
+
+// planEviction lista as entradas a remover sem alterar o cache. Traduções
+// humanas nunca expiram.
+func planEviction(data map[string]map[string]CacheEntry, p cachePolicy, now time.Time) []evictItem {
+	var plan, kept []evictItem
+	var size int64
+	for lang, entries := range data {
+		for key, e := range entries {
+			if e.Human {
+				continue
+			}
+			item := evictItem{lang: lang, key: key, entry: e}
+			if p.TTL > 0 && now.Sub(e.LastUsed) > p.TTL {
+				item.expired = true
+				plan = append(plan, item)
+				continue
+			}
+			kept = append(kept, item)
+			size += cacheEntrySize(lang, key, e)
+		}
+	}
+	if p.MaxEntries <= 0 && p.MaxBytes <= 0 {
+		return plan
+	}
+	sort.Slice(kept, func(i, j int) bool {
+		if !kept[i].entry.LastUsed.Equal(kept[j].entry.LastUsed) {
+			return kept[i].entry.LastUsed.Before(kept[j].entry.LastUsed)
+		}
+		return kept[i].lang+kept[i].key < kept[j].lang+kept[j].key
+	})
+	count := len(kept)
+	for _, item := range kept {
+		if (p.MaxEntries <= 0 || count <= p.MaxEntries) && (p.MaxBytes <= 0 || size <= p.MaxBytes) {
+			break
+		}
+		plan = append(plan, item)
+		count--
+		size -= cacheEntrySize(item.lang, item.key, item.entry)
+	}
+	return plan
+}
+
+// cacheEntrySize estima o espaço da entrada no banco: os campos mais o
+// cabeçalho da linha e do índice.
+func cacheEntrySize(lang, key string, e CacheEntry) int64 {
+	return int64(2*len(lang)+len(key)+len(e.Value)+2*len(e.Source)+2*len(e.From)+len(e.Engine)) + 24
+}
+
+// applyEviction remove as entradas do banco (e de cacheData, quando
+// carregado) e devolve ao sistema as páginas liberadas.
+func applyEviction(plan []evictItem) {
+	if len(plan) == 0 {
+		return
+	}
+	storage.batch(func() {
+		for _, item := range plan {
+			delete(cacheData[item.lang], item.key)
+			if len(cacheData[item.lang]) == 0 {
+				delete(cacheData, item.lang)
+			}
+			storage.del(item.lang, item.key)
+		}
+	})
+	if storage.db != nil {
+		storage.db.Exec("PRAGMA incremental_vacuum")
+	}
+}
+
+// --- SUBCOMANDOS DO CACHE ---
+
+func runCacheCommand(args []string) int {
+	if len(args) == 0 {
+		cacheUsage()
+		return 1
+	}
+	switch args[0] {
+	case "verify":
+		return cacheVerify(args[1:])
+	case "stats":
+		return cacheStats(args[1:])
+	case "search":
+		return cacheSearch(args[1:])
+	case "show":
+		return cacheShow(args[1:])
+	case "set":
+		return cacheSet(args[1:])
+	case "delete":
+		return cacheDelete(args[1:])
+	case "evict":
+		return cacheEvict(args[1:])
+	case "serve":
+		return cacheServe(args[1:])
+	case "merge":
+		return cacheMerge(args[1:])
+	case "export":
+		return cacheExport(args[1:])
+	case "import":
+		return cacheImport(args[1:])
+	case "import-po":
+		return cacheImportPO(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "%s %s: %s\n", red(T("ERRO:")), white(T("Subcomando desconhecido")), args[0])
+		cacheUsage()
+		return 1
+	}
+}
+
+func cacheUsage() {
+	fmt.Fprintf(os.Stderr, "\n%s: %s %s %s\n\n", yellow(T("Uso")), green(_APP_), yellow("cache"), green(T("<subcomando> [opções]")))
+	cmds := []struct{ name, desc string }{
+		{"stats", T("Entradas por idioma e motor, tamanho e idade")},
+		{"search <regex>", T("Procura no texto de origem (--value: na tradução)")},
+		{"show <texto>", T("Mostra as traduções guardadas de um texto")},
+		{"set <texto> <tradução>", T("Corrige à mão a tradução de um texto (-l obrigatório)")},
+		{"delete <regex>", T("Remove as entradas cujo texto casa com o padrão")},
+		{"export [-o arquivo.tmx]", T("Exporta o cache como memória de tradução TMX")},
+		{"import <arquivo.tmx>...", T("Importa memórias TMX (--overwrite substitui as existentes; --human grava como humanas)")},
+		{"import-po <arquivo.po>...", T("Importa traduções revisadas de catálogos PO como humanas")},
+		{"evict [--dry-run]", T("Aplica a política de expurgo (--ttl, --max-entries, --max-size)")},
+		{"merge <a> <b>... -o <saída>", T("Junta caches de várias máquinas (--strategy newest|human|engine:<motor>)")},
+		{"serve [--listen 127.0.0.1:8787]", T("Serve este cache para a equipe (--cache-url nos clientes)")},
+		{"verify [--repair]", T("Verifica o cache; --repair salva as entradas legíveis")},
+	}
+	for _, c := range cmds {
+		fmt.Fprintf(os.Stderr, "  %-32s %s\n", cyan(c.name), white(c.desc))
+	}
+	fmt.Fprintf(os.Stderr, "\n%s:\n", yellow(T("Filtros")))
+	filters := []struct{ short, long, desc string }{
+		{"-l", "--language", T("Idiomas de destino (ex: es,fr)")},
+		{"-e", "--engine", T("Motor (ex: google, deepl, llm:llama3.1)")},
+		{"-s", "--source", T("Idioma de origem")},
+	}
+	for _, f := range filters {
+		fmt.Fprintf(os.Stderr, "  %s, %-30s %s\n", cyan(f.short), cyan(f.long), white(f.desc))
+	}
+}
+
+// newCacheFlagSet cria o conjunto de flags de um subcomando do cache.
+func newCacheFlagSet(name string) *pflag.FlagSet {
+	fs := pflag.NewFlagSet("cache "+name, pflag.ContinueOnError)
+	fs.StringVar(&cacheFile, "cache-file", cacheFile, T("Arquivo do cache"))
+	fs.StringVar(&configFile, "config", configFile, T("Arquivo de configuração"))
+	fs.Usage = cacheUsage
+	return fs
+}
+
+func cacheVerify(args []string) int {
+	fs := newCacheFlagSet("verify")
+	repair := fs.Bool("repair", false, T("Reescreve o cache só com as entradas legíveis"))
+	if !parseCacheFlags(fs, args) {
+		return 1
+	}
+
+	fmt.Printf("\n%s %s %s\n", cyan(">>"), white(_APP_), white("cache verify"))
+	_, statErr := os.Stat(cacheFile)
+	legacyData, legacyErr := os.ReadFile(legacyCache)
+	if os.IsNotExist(statErr) && legacyErr != nil {
+		fmt.Printf("%s %s\n", yellow(T("[AVISO]")), white(T("Nenhum cache encontrado.")))
+		return 0
+	}
+
+	var current map[string]map[string]CacheEntry
+	var problems []string
+	var err error
+	damaged := false
+	if statErr == nil {
+		current, problems, err = checkCacheDB(cacheFile)
+		damaged = err != nil || len(problems) > 0
+	}
+	var salvaged map[string]map[string]CacheEntry
+	if legacyErr == nil {
+		var legacyOK bool
+		salvaged, legacyOK = salvageLegacyCache(legacyData)
+		fmt.Printf("    → %-15s: %s (%d %s)\n", T("cache.json"), white(legacyCache), countEntries(salvaged), T("entradas legíveis"))
+		damaged = damaged || !legacyOK
+	}
+
+	fmt.Printf("    → %-15s: %s\n", T("Arquivo"), white(cacheFile))
+	fmt.Printf("    → %-15s: %d\n", T("Entradas"), countEntries(current))
+	if len(problems) > 0 {
+		shown := problems
+		if len(shown) > 10 {
+			shown = shown[:10]
+		}
+		fmt.Printf("    → %-15s: %s\n", T("Problemas"), red(len(problems)))
+		for _, p := range shown {
+			fmt.Printf("        %s\n", white(p))
+		}
+	}
+	if err != nil {
+		fmt.Printf("    → %-15s: %s\n", T("Erro"), red(err.Error()))
+	}
+
+	if !damaged {
+		fmt.Printf("%s %s\n", green("✔"), white(T("Cache íntegro.")))
+		return 0
+	}
+	if !*repair {
+		fmt.Printf("%s %s\n", red("✖"), white(T("Cache danificado; execute novamente com --repair.")))
+		return 1
+	}
+
+	// O reparo troca o arquivo: não pode haver outra execução com ele aberto.
+	unlock, err := lockCache(syscall.LOCK_EX | syscall.LOCK_NB)
+	if err != nil {
+		fmt.Printf("%s %s\n", red(T("ERRO:")), white(T("Cache em uso por outra execução; tente de novo quando ela terminar.")))
+		return 1
+	}
+	defer unlock()
+	data := upgradeCacheV1(salvaged)
+	for lang, entries := range current {
+		if data[lang] == nil {
+			data[lang] = make(map[string]CacheEntry)
+		}
+		for key, e := range entries {
+			data[lang][key] = e
+		}
+	}
+	if err := backupCache(); err != nil {
+		fmt.Printf("%s %s: %v\n", red(T("ERRO:")), white(T("Falha ao copiar o cache")), err)
+		return 1
+	}
+	if err := writeCacheSnapshot(cacheFile, data); err != nil {
+		fmt.Printf("%s %s: %v\n", red(T("ERRO:")), white(T("Falha ao reparar o cache")), err)
+		return 1
+	}
+	if legacyErr == nil {
+		os.Rename(legacyCache, legacyCache+".migrated")
+	}
+	fmt.Printf("%s %s %d %s", green("✔"), white(T("Cache reparado:")), countEntries(data), T("entradas salvas"))
+	if statErr == nil {
+		fmt.Printf(" (%s %s)", T("original em"), cyan(cacheFile+".bak.1"))
+	}
+	fmt.Println()
+	return 0
+}
+
+// checkCacheDB abre o banco só para leitura, roda o integrity_check do
+// SQLite e lê todas as entradas que ainda estiverem legíveis.
+func checkCacheDB(path string) (map[string]map[string]CacheEntry, []string, error) {
+	data := make(map[string]map[string]CacheEntry)
+	db, err := openCacheDB(path, true)
+	if err != nil {
+		return data, nil, err
+	}
+	defer db.Close()
+	var problems []string
+	rows, err := db.Query("PRAGMA integrity_check")
+	if err != nil {
+		problems = append(problems, err.Error())
+	} else {
+		for rows.Next() {
+			var msg string
+			if rows.Scan(&msg) == nil && msg != "ok" {
+				problems = append(problems, msg)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			problems = append(problems, err.Error())
+		}
+		rows.Close()
+	}
+	data, err = readCacheRows(db)
+	return data, problems, err
+}
+
+// salvageLegacyCache lê o antigo cache.json entrada por entrada, de modo que
+// um arquivo truncado ainda entregue tudo o que veio antes do ponto do corte.
+func salvageLegacyCache(data []byte) (map[string]map[string]CacheEntry, bool) {
+	out := make(map[string]map[string]CacheEntry)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return out, false
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return out, false
+		}
+		lang, _ := tok.(string)
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+			return out, false
+		}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return out, false
+			}
+			key, _ := tok.(string)
+			var e CacheEntry
+			if err := dec.Decode(&e); err != nil {
+				return out, false
+			}
+			if out[lang] == nil {
+				out[lang] = make(map[string]CacheEntry)
+			}
+			out[lang][key] = e
+		}
+		if _, err := dec.Token(); err != nil {
+			return out, false
+		}
+	}
+	_, err := dec.Token()
+	return out, err == nil
+}
+
+// cacheFilter restringe os subcomandos a idiomas, motor e origem.
+type cacheFilter struct {
+	langs  string
+	engine string
+	from   string
+}
+
+func addCacheFilterFlags(fs *pflag.FlagSet) *cacheFilter {
+	f := &cacheFilter{}
+	fs.StringVarP(&f.langs, "language", "l", "", T("Idiomas de destino"))
+	fs.StringVarP(&f.engine, "engine", "e", "", T("Motor"))
+	fs.StringVarP(&f.from, "source", "s", "", T("Idioma de origem"))
+	return f
+}
+
+func (f *cacheFilter) match(lang string, e CacheEntry) bool {
+	if f.langs != "" && !containsString(strings.Split(f.langs, ","), lang) {
+		return false
+	}
+	if f.engine != "" && e.Engine != f.engine {
+		return false
+	}
+	return f.from == "" || e.From == f.from
+}
+
+// cacheEach percorre as entradas aceitas pelo filtro em ordem estável.
+func cacheEach(f *cacheFilter, fn func(lang, key string, e CacheEntry)) {
+	for _, lang := range sortedKeys(cacheData) {
+		for _, key := range sortedKeys(cacheData[lang]) {
+			if e := cacheData[lang][key]; f.match(lang, e) {
+				fn(lang, key, e)
+			}
+		}
+	}
+}
+
+func printCacheEntry(lang, key string, e CacheEntry) {
+	source := white(cacheSourceText(key, e))
+	if e.Source == "" {
+		source += " " + blue(T("(esquema 1, em minúsculas)"))
+	}
+	fmt.Printf("  %s %s %s\n      %s %s\n", cyan("["+lang+"]"), yellow(firstNonEmpty(e.Engine, "?")+"|"+firstNonEmpty(e.From, "auto")),
+		source, blue("→"), green(e.Value))
+}
+
+func cacheStats(args []string) int {
+	fs := newCacheFlagSet("stats")
+	filter := addCacheFilterFlags(fs)
+	if !parseCacheFlags(fs, args) {
+		return 1
+	}
+	path, ok := readCacheEntries()
+	if !ok {
+		return 1
+	}
+
+	perLang := make(map[string]int)
+	perEngine := make(map[string]int)
+	ages := []struct {
+		label string
+		limit time.Duration
+		count int
+	}{
+		{T("até 1 dia"), 24 * time.Hour, 0},
+		{T("até 7 dias"), 7 * 24 * time.Hour, 0},
+		{T("até 30 dias"), 30 * 24 * time.Hour, 0},
+		{T("até 90 dias"), 90 * 24 * time.Hour, 0},
+		{T("mais antigas"), math.MaxInt64, 0},
+	}
+	total := 0
+	cacheEach(filter, func(lang, key string, e CacheEntry) {
+		total++
+		perLang[lang]++
+		perEngine[firstNonEmpty(e.Engine, "?")]++
+		age := time.Since(e.LastUsed)
+		for i := range ages {
+			if age < ages[i].limit {
+				ages[i].count++
+				break
+			}
+		}
+	})
+
+	fmt.Printf("\n%s %s %s\n", cyan(">>"), white(_APP_), white("cache stats"))
+	size := int64(0)
+	if info, err := os.Stat(path); err == nil {
+		size = info.Size()
+	}
+	fmt.Printf("    → %-15s: %s (%.1f KiB)\n", T("Arquivo"), white(path), float64(size)/1024)
+	fmt.Printf("    → %-15s: %d\n", T("Entradas"), total)
+
+	fmt.Printf("\n%s\n", yellow(T("Por idioma:")))
+	for _, lang := range sortedKeys(perLang) {
+		fmt.Printf("    %-10s %8d\n", lang, perLang[lang])
+	}
+	fmt.Printf("\n%s\n", yellow(T("Por motor:")))
+	for _, name := range sortedKeys(perEngine) {
+		fmt.Printf("    %-24s %8d\n", name, perEngine[name])
+	}
+	fmt.Printf("\n%s\n", yellow(T("Último uso:")))
+	for _, a := range ages {
+		bar := ""
+		if total > 0 {
+			bar = strings.Repeat("█", a.count*40/total)
+		}
+		fmt.Printf("    %-14s %8d %s\n", a.label, a.count, cyan(bar))
+	}
+	return 0
+}
+
+func cacheSearch(args []string) int {
+	fs := newCacheFlagSet("search")
+	filter := addCacheFilterFlags(fs)
+	inValue := fs.Bool("value", false, T("Procura na tradução em vez do texto de origem"))
+	limit := fs.Int("limit", 100, T("Máximo de resultados; 0 mostra todos"))
+	if !parseCacheFlags(fs, args) {
+		return 1
+	}
+	re, ok := cachePattern(fs.Args())
+	if !ok {
+		return 1
+	}
+	if _, ok := readCacheEntries(); !ok {
+		return 1
+	}
+
+	found := 0
+	cacheEach(filter, func(lang, key string, e CacheEntry) {
+		if !re.MatchString(cacheMatchField(key, e, *inValue)) {
+			return
+		}
+		found++
+		if *limit == 0 || found <= *limit {
+			printCacheEntry(lang, key, e)
+		}
+	})
+	fmt.Printf("\n%s %d %s\n", green("✔"), found, T("entradas encontradas."))
+	return 0
+}
+
+func cacheShow(args []string) int {
+	fs := newCacheFlagSet("show")
+	filter := addCacheFilterFlags(fs)
+	if !parseCacheFlags(fs, args) {
+		return 1
+	}
+	if fs.NArg() != 1 {
+		cacheUsage()
+		return 1
+	}
+	text := normalizeSource(fs.Arg(0))
+	if _, ok := readCacheEntries(); !ok {
+		return 1
+	}
+
+	found := 0
+	cacheEach(filter, func(lang, key string, e CacheEntry) {
+		if !cacheSourceIs(key, e, text) {
+			return
+		}
+		found++
+		printCacheEntry(lang, key, e)
+		fmt.Printf("      %s %s\n", T("último uso:"), e.LastUsed.Local().Format("2006-01-02 15:04"))
+	})
+	if found == 0 {
+		fmt.Printf("%s %s\n", yellow(T("[AVISO]")), white(T("Texto não encontrado no cache.")))
+		return 1
+	}
+	return 0
+}
+
+// cacheSet corrige a tradução de um texto em todas as entradas existentes
+// que casam com o filtro; sem nenhuma, cria a entrada do motor pedido (por
+// padrão, uma tradução humana válida para todos os motores).
+func cacheSet(args []string) int {
+	fs := newCacheFlagSet("set")
+	filter := addCacheFilterFlags(fs)
+	if !parseCacheFlags(fs, args) {
+		return 1
+	}
+	if fs.NArg() != 2 || filter.langs == "" {
+		cacheUsage()
+		return 1
+	}
+	text, value := normalizeSource(fs.Arg(0)), fs.Arg(1)
+	loadCacheEntries()
+	defer saveCache()
+
+	now := time.Now()
+	changed := 0
+	storage.batch(func() {
+		cacheEach(filter, func(lang, key string, e CacheEntry) {
+			if !cacheSourceIs(key, e, text) {
+				return
+			}
+			e.Value, e.LastUsed, e.Human = value, now, true
+			storage.put(lang, key, e)
+			changed++
+		})
+		if changed > 0 {
+			return
+		}
+		engineKey, from := firstNonEmpty(filter.engine, humanEngine), firstNonEmpty(filter.from, "auto")
+		key := cacheKey(engineKey, from, text)
+		for _, lang := range strings.Split(filter.langs, ",") {
+			e := CacheEntry{Value: value, LastUsed: now, Source: text, From: from, Engine: engineKey, Human: true}
+			storage.put(lang, key, e)
+			changed++
+		}
+	})
+	fmt.Printf("%s %d %s\n", green("✔"), changed, T("entradas atualizadas."))
+	return 0
+}
+
+func cacheDelete(args []string) int {
+	fs := newCacheFlagSet("delete")
+	filter := addCacheFilterFlags(fs)
+	inValue := fs.Bool("value", false, T("Casa o padrão com a tradução em vez do texto de origem"))
+	dryRun := fs.Bool("dry-run", false, T("Só lista o que seria removido"))
+	if !parseCacheFlags(fs, args) {
+		return 1
+	}
+	re, ok := cachePattern(fs.Args())
+	if !ok {
+		return 1
+	}
+	loadCacheEntries()
+	if !*dryRun {
+		defer saveCache()
+	}
+
+	removed := 0
+	storage.batch(func() {
+		cacheEach(filter, func(lang, key string, e CacheEntry) {
+			if !re.MatchString(cacheMatchField(key, e, *inValue)) {
+				return
+			}
+			removed++
+			if *dryRun {
+				printCacheEntry(lang, key, e)
+				return
+			}
+			storage.del(lang, key)
+		})
+	})
+	if *dryRun {
+		fmt.Printf("\n%s %d %s\n", yellow("[DRY-RUN]"), removed, T("entradas seriam removidas."))
+	} else {
+		fmt.Printf("%s %s %d %s\n", green("✔"), T("Removidas"), removed, T("entradas do cache."))
+	}
+	return 0
+}
+
+func cacheEvict(args []string) int {
+	fs := newCacheFlagSet("evict")
+	fs.StringVar(&cacheTTL, "ttl", "", T("Remove entradas sem uso há mais que isso (ex: 30d, 720h)"))
+	fs.IntVar(&cacheMaxEntries, "max-entries", 0, T("Máximo de entradas; as de uso mais antigo saem primeiro"))
+	fs.StringVar(&cacheMaxSize, "max-size", "", T("Tamanho máximo do cache (ex: 50M)"))
+	dryRun := fs.Bool("dry-run", false, T("Só mostra o que seria removido em cada idioma"))
+	limit := fs.Int("limit", 10, T("Entradas listadas por idioma no --dry-run; 0 lista todas"))
+	if !parseCacheFlags(fs, args) {
+		return 1
+	}
+	if !evictPolicy.active() {
+		fmt.Fprintf(os.Stderr, "%s %s\n", yellow(T("[AVISO]")), white(T("Nenhuma política definida (--ttl, --max-entries, --max-size ou seção \"cache\" da configuração).")))
+		return 1
+	}
+	loadCacheEntries()
+	plan := planEviction(cacheData, evictPolicy, time.Now())
+
+	perLang := make(map[string][]evictItem)
+	for _, item := range plan {
+		perLang[item.lang] = append(perLang[item.lang], item)
+	}
+	fmt.Printf("\n%s %s %s\n", cyan(">>"), white(_APP_), white("cache evict"))
+	for _, lang := range sortedKeys(perLang) {
+		items := perLang[lang]
+		sort.Slice(items, func(i, j int) bool { return items[i].entry.LastUsed.Before(items[j].entry.LastUsed) })
+		expired := 0
+		for _, item := range items {
+			if item.expired {
+				expired++
+			}
+		}
+		fmt.Printf("    → %-10s %6d / %-6d %s (%d %s, %d %s)\n", lang, len(items), len(cacheData[lang]), T("entradas"),
+			expired, T("expiradas"), len(items)-expired, T("acima do limite"))
+		if !*dryRun {
+			continue
+		}
+		for i, item := range items {
+			if *limit > 0 && i >= *limit {
+				fmt.Printf("        %s\n", white(fmt.Sprintf(T("... e mais %d"), len(items)-i)))
+				break
+			}
+			fmt.Printf("        %s %s %s\n", yellow(item.entry.LastUsed.Local().Format("2006-01-02")),
+				cyan(firstNonEmpty(item.entry.Engine, "?")), white(item.entry.Source))
+		}
+	}
+
+	if *dryRun {
+		fmt.Printf("\n%s %d %s\n", yellow("[DRY-RUN]"), len(plan), T("entradas seriam removidas."))
+		return 0
+	}
+	applyEviction(plan)
+	saveCache()
+	fmt.Printf("\n%s %s %d %s\n", green("✔"), T("Removidas"), len(plan), T("entradas do cache."))
+	return 0
+}
+
+// parseCacheFlags lê as flags do subcomando e a configuração, de onde vem a
+// política de expurgo aplicada ao salvar.
+func parseCacheFlags(fs *pflag.FlagSet, args []string) bool {
+	if err := fs.Parse(args); err != nil {
+		return false
+	}
+	loadConfig()
+	if err := resolveCachePolicy(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s %s\n", red(T("ERRO:")), white(err.Error()))
+		return false
+	}
+	return true
+}
+
+func cachePattern(args []string) (*regexp.Regexp, bool) {
+	if len(args) != 1 {
+		cacheUsage()
+		return nil, false
+	}
+	re, err := regexp.Compile(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %s: %v\n", red(T("ERRO:")), white(T("Expressão regular inválida")), err)
+		return nil, false
+	}
+	return re, true
+}
+
+func cacheMatchField(key string, e CacheEntry, value bool) string {
+	if value {
+		return e.Value
+	}
+	return cacheSourceText(key, e)
+}
+
+// cacheSourceIs compara pelo texto exato; entradas migradas do esquema 1
+// guardam o texto em minúsculas e casam pela chave.
+func cacheSourceIs(key string, e CacheEntry, text string) bool {
+	if e.Source == "" {
+		return key == legacyCacheKey(text)
+	}
+	return e.Source == text
+}
+
+func countEntries(data map[string]map[string]CacheEntry) int {
+	total := 0
+	for _, entries := range data {
+		total += len(entries)
+	}
+	return total
+}
+
+// cacheImportPO grava no cache as traduções revisadas de catálogos PO, como
+// entradas humanas: elas valem para qualquer motor e o --force não as
+// substitui.
+func cacheImportPO(args []string) int {
+	fs := newCacheFlagSet("import-po")
+	filter := addCacheFilterFlags(fs)
+	if !parseCacheFlags(fs, args) {
+		return 1
+	}
+	if fs.NArg() == 0 {
+		cacheUsage()
+		return 1
+	}
+	loadCache()
+	defer saveCache()
+
+	from := firstNonEmpty(filter.from, "auto")
+	status := 0
+	for _, path := range fs.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", red(T("ERRO:")), err)
+			status = 1
+			continue
+		}
+		po, err := parsePO(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %s %s: %v\n", red(T("ERRO:")), white(T("PO inválido")), yellow(path), err)
+			status = 1
+			continue
+		}
+		lang := cacheLang(po.HeaderValue("Language"))
+		if lang == "" {
+			lang = filter.langs
+		}
+		if lang == "" || strings.Contains(lang, ",") {
+			fmt.Fprintf(os.Stderr, "%s %s %s\n", red(T("ERRO:")), yellow(path), white(T("não tem 'Language:'; informe o idioma com -l")))
+			status = 1
+			continue
+		}
+
+		added, fuzzy, empty, plural := 0, 0, 0, 0
+		now := time.Now()
+		storage.batch(func() {
+			for _, entry := range po.Entries {
+				switch {
+				case entry.Obsolete:
+					continue
+				case entry.Plural != "":
+					plural++
+					continue
+				case entry.hasFlag("fuzzy"):
+					fuzzy++
+					continue
+				case !entry.translated() || normalizeSource(entry.ID) == "":
+					empty++
+					continue
+				}
+				key := cacheKey(humanEngine, from, entry.ID)
+				e := CacheEntry{Value: entry.Str[0], LastUsed: now, Source: normalizeSource(entry.ID), From: from, Engine: humanEngine, Human: true}
+				storage.put(lang, key, e)
+				added++
+			}
+		})
+		fmt.Printf("%s %s [%s]: %d %s (%d fuzzy, %d %s, %d %s)\n", green("✔"), cyan(path), lang, added, T("importadas"),
+			fuzzy, empty, T("vazias"), plural, T("plurais"))
+	}
+	return status
+}
+
+// mergeStrategy escolhe entre duas traduções do mesmo texto (de qualquer
+// motor, humanas ou não): devolve true quando a e deve ficar no lugar de cur.
+type mergeStrategy func(e, cur CacheEntry) bool
+
+func newestWins(e, cur CacheEntry) bool {
+	return e.LastUsed.After(cur.LastUsed)
+}
+
+func parseMergeStrategy(name string) (mergeStrategy, error) {
+	switch {
+	case name == "newest":
+		return newestWins, nil
+	case name == "human":
+		return func(e, cur CacheEntry) bool {
+			if e.Human != cur.Human {
+				return e.Human
+			}
+			return newestWins(e, cur)
+		}, nil
+	case strings.HasPrefix(name, "engine:") && len(name) > len("engine:"):
+		preferred := strings.TrimPrefix(name, "engine:")
+		return func(e, cur CacheEntry) bool {
+			if (e.Engine == preferred) != (cur.Engine == preferred) {
+				return e.Engine == preferred
+			}
+			return newestWins(e, cur)
+		}, nil
+	}
+	return nil, fmt.Errorf("%s: %s", T("Estratégia desconhecida"), name)
+}
+
+// readCacheFile lê, sem alterar, um cache em qualquer formato que o
+// chili-tradutor-go já gravou: o banco SQLite (cache.db) ou o mapa JSON
+// (cache.json).
+func readCacheFile(path string) (map[string]map[string]CacheEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, []byte("SQLite format 3\x00")) {
+		db, err := openCacheDB(path, true)
+		if err != nil {
+			return nil, err
+		}
+		defer db.Close()
+		return readCacheRows(db)
+	}
+	old := make(map[string]map[string]CacheEntry)
+	if err := json.Unmarshal(data, &old); err != nil {
+		return nil, err
+	}
+	return upgradeCacheV1(old), nil
+}
+
+type mergeCandidate struct {
+	file  string
+	key   string
+	entry CacheEntry
+	kept  bool
+}
+
+// mergeGroup identifica o texto traduzido por uma entrada: idioma de origem e
+// texto normalizado, sem o motor. Entradas sem texto de origem ficam sozinhas.
+func mergeGroup(key string, e CacheEntry) string {
+	if e.Source == "" {
+		return "\x00" + key
+	}
+	return e.From + "|" + normalizeSource(e.Source)
+}
+
+// resolveMerge junta os caches. Só disputam entre si as entradas de mesma
+// chave (mesmo motor, origem e texto): a preferida pela estratégia é gravada
+// e as demais descartadas; chaves sem colisão passam intactas. Para o
+// relatório, as traduções de cada texto são agrupadas entre todos os motores
+// e, por grupo, é devolvido o índice da que a estratégia prefere.
+func resolveMerge(files []string, sets []map[string]map[string]CacheEntry, prefer mergeStrategy) (map[string]map[string]CacheEntry, map[string]map[string][]mergeCandidate, map[string]map[string]int) {
+	groups := make(map[string]map[string][]mergeCandidate)
+	for i, data := range sets {
+		for lang, entries := range data {
+			if groups[lang] == nil {
+				groups[lang] = make(map[string][]mergeCandidate)
+			}
+			for _, key := range sortedKeys(entries) {
+				e := entries[key]
+				id := mergeGroup(key, e)
+				groups[lang][id] = append(groups[lang][id], mergeCandidate{file: files[i], key: key, entry: e})
+			}
+		}
+	}
+	merged := make(map[string]map[string]CacheEntry)
+	winners := make(map[string]map[string]int)
+	for lang, byText := range groups {
+		merged[lang] = make(map[string]CacheEntry)
+		winners[lang] = make(map[string]int)
+		for id, cands := range byText {
+			byKey := make(map[string]int)
+			best := 0
+			for i, c := range cands {
+				if k, ok := byKey[c.key]; !ok || prefer(c.entry, cands[k].entry) {
+					byKey[c.key] = i
+				}
+				if prefer(c.entry, cands[best].entry) {
+					best = i
+				}
+			}
+			for key, i := range byKey {
+				cands[i].kept = true
+				merged[lang][key] = cands[i].entry
+			}
+			winners[lang][id] = best
+		}
+	}
+	return merged, groups, winners
+}
+
+func cacheMerge(args []string) int {
+	fs := newCacheFlagSet("merge")
+	output := fs.StringP("output", "o", "", T("Cache resultante (.json ou cache.db)"))
+	strategy := fs.String("strategy", "newest", T("Em conflito: newest, human ou engine:<motor>"))
+	if !parseCacheFlags(fs, args) {
+		return 1
+	}
+	prefer, err := parseMergeStrategy(*strategy)
+	if err != nil || *output == "" || fs.NArg() == 0 {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %s\n", red(T("ERRO:")), white(err.Error()))
+		}
+		cacheUsage()
+		return 1
+	}
+
+	var sets []map[string]map[string]CacheEntry
+	for _, path := range fs.Args() {
+		data, err := readCacheFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %s: %v\n", red(T("ERRO:")), yellow(path), err)
+			return 1
+		}
+		sets = append(sets, data)
+		fmt.Printf("%s %s: %d %s\n", cyan("→"), path, countEntries(data), T("entradas"))
+	}
+	merged, texts, winners := resolveMerge(fs.Args(), sets, prefer)
+
+	conflicts := 0
+	for _, lang := range sortedKeys(texts) {
+		header := false
+		for _, id := range sortedKeys(texts[lang]) {
+			cands := texts[lang][id]
+			values := make(map[string]bool)
+			for _, c := range cands {
+				values[c.entry.Value] = true
+			}
+			best := winners[lang][id]
+			if len(values) < 2 {
+				continue
+			}
+			conflicts++
+			if !header {
+				fmt.Printf("\n%s\n", yellow(fmt.Sprintf(T("Conflitos em %s:"), lang)))
+				header = true
+			}
+			fmt.Printf("  %s\n", white(cands[0].entry.Source))
+			for i, c := range cands {
+				mark, flag := " ", ""
+				if i == best {
+					mark = green("→")
+				}
+				if c.entry.Human {
+					flag = " " + green(T("[humana]"))
+				}
+				if !c.kept {
+					flag += " " + red(T("[descartada]"))
+				}
+				fmt.Printf("    %s %s %s (%s, %s)%s\n", mark, cyan(firstNonEmpty(c.entry.Engine, "?")), c.entry.Value,
+					c.file, c.entry.LastUsed.Local().Format("2006-01-02"), flag)
+			}
+		}
+	}
+
+	if err := writeMergedCache(*output, merged); err != nil {
+		fmt.Fprintf(os.Stderr, "%s %s: %v\n", red(T("ERRO:")), white(T("Falha ao gravar")), err)
+		return 1
+	}
+	fmt.Printf("\n%s %d %s %s (%d %s, %s: %s)\n", green("✔"), countEntries(merged), T("entradas gravadas em"), cyan(*output),
+		conflicts, T("conflitos"), T("estratégia"), *strategy)
+	return 0
+}
+
+// writeMergedCache grava em .json o mapa idioma -> chave -> entrada e, nos
+// demais casos, um banco SQLite pronto para uso como cache.db. Se o destino
+// é o próprio cache, as entradas são gravadas nele.
+func writeMergedCache(path string, data map[string]map[string]CacheEntry) error {
+	if filepath.Ext(path) == ".json" {
+		out, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeFileAtomic(path, append(out, '\n'), 0644)
+	}
+	if abs, err := filepath.Abs(path); err == nil && abs == cacheFile {
+		// O resultado contém todas as chaves do cache atual: basta gravar
+		// por cima, sem tirar o banco de quem o estiver usando.
+		loadCache()
+		if storage.db == nil {
+			return fmt.Errorf(T("%s: falha ao abrir"), path)
+		}
+		storage.batch(func() {
+			for lang, entries := range data {
+				for key, e := range entries {
+					storage.put(lang, key, e)
+				}
+			}
+		})
+		if storage.failed {
+			return fmt.Errorf(T("%s: falha ao gravar"), path)
+		}
+		return nil
+	}
+	return writeCacheSnapshot(path, data)
+}
+
+// --- CACHE COMPARTILHADO ---
+
+// remoteCache é o cliente do servidor de cache da equipe (--cache-url). O
+// cache local continua sendo a cópia de leitura: o servidor só é consultado
+// quando o local não tem a entrada, e o que vem de lá é gravado localmente.
+// Na primeira falha de rede o cliente passa a trabalhar só com o local.
+type remoteCache struct {
+	base    string
+	token   string
+	client  *http.Client
+	offline atomic.Bool
+	warn    sync.Once
+}
+
+var remote *remoteCache
+
+func setupRemoteCache() {
+	u := firstNonEmpty(cacheURL, config.Cache.URL)
+	if u == "" {
+		return
+	}
+	remote = &remoteCache{
+		base:   strings.TrimRight(u, "/"),
+		token:  firstNonEmpty(cacheToken, os.Getenv("CHILI_CACHE_TOKEN"), config.Cache.Token),
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (r *remoteCache) request(method, lang string, keys []string, body io.Reader) (*http.Response, error) {
+	q := url.Values{"l": {lang}}
+	for _, k := range keys {
+		q.Add("k", k)
+	}
+	req, err := http.NewRequestWithContext(appCtx, method, r.base+"/v1/entries?"+q.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+	resp, err := r.client.Do(req)
+	if err == nil && resp.StatusCode >= 300 {
+		resp.Body.Close()
+		err = fmt.Errorf("%s %s: %s", method, r.base, resp.Status)
+	}
+	return resp, err
+}
+
+func (r *remoteCache) get(lang string, keys []string) map[string]CacheEntry {
+	if r.offline.Load() {
+		return nil
+	}
+	resp, err := r.request(http.MethodGet, lang, keys, nil)
+	if err != nil {
+		r.fail(err)
+		return nil
+	}
+	defer resp.Body.Close()
+	found := make(map[string]CacheEntry)
+	if err := json.NewDecoder(resp.Body).Decode(&found); err != nil {
+		r.fail(err)
+		return nil
+	}
+	return found
+}
+
+func (r *remoteCache) put(lang string, entries map[string]CacheEntry) {
+	if r.offline.Load() {
+		return
+	}
+	body, err := json.Marshal(entries)
+	if err != nil {
+		return
+	}
+	resp, err := r.request(http.MethodPut, lang, nil, bytes.NewReader(body))
+	if err != nil {
+		r.fail(err)
+		return
+	}
+	resp.Body.Close()
+}
+
+func (r *remoteCache) fail(err error) {
+	if appCtx.Err() != nil {
+		return
+	}
+	r.offline.Store(true)
+	r.warn.Do(func() {
+		muConsole.Lock()
+		defer muConsole.Unlock()
+		fmt.Fprintf(os.Stderr, "\n%s %s: %v\n", yellow(T("[AVISO]")), white(T("Servidor de cache inacessível; usando só o cache local")), err)
+	})
+}
+
+// mergeRemoteEntry grava localmente uma entrada vinda do servidor. Uma
+// tradução humana local não é trocada por uma de motor.
+func mergeRemoteEntry(lang, key string, e CacheEntry) {
+	if old, ok := storage.get(lang, key); ok && old.Human && !e.Human {
+		return
+	}
+	storage.put(lang, key, e)
+}
+
+func cacheServe(args []string) int {
+	fs := newCacheFlagSet("serve")
+	listen := fs.String("listen", "127.0.0.1:8787", T("Endereço do servidor"))
+	token := fs.String("token", os.Getenv("CHILI_CACHE_TOKEN"), T("Token exigido dos clientes (padrão: $CHILI_CACHE_TOKEN)"))
+	if !parseCacheFlags(fs, args) {
+		return 1
+	}
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", red(T("ERRO:")), err)
+		return 1
+	}
+	// Fora da própria máquina, só com token: o servidor aceita escritas.
+	if addr, ok := ln.Addr().(*net.TCPAddr); ok && !addr.IP.IsLoopback() && *token == "" {
+		ln.Close()
+		fmt.Fprintf(os.Stderr, "%s %s\n", red(T("ERRO:")), T("para servir fora de 127.0.0.1 use --token ou $CHILI_CACHE_TOKEN"))
+		return 1
+	}
+	loadCache()
+	defer saveCache()
+	srv := &http.Server{Handler: cacheServerHandler(*token), ReadHeaderTimeout: 10 * time.Second}
+	go srv.Serve(ln)
+	fmt.Printf("%s %s %s (%d %s)\n", green("✔"), white(T("Servidor de cache em")), cyan("http://"+ln.Addr().String()), storage.count(), T("entradas"))
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			saveCache()
+		case <-sigs:
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			srv.Shutdown(ctx)
+			cancel()
+			return 0
+		}
+	}
+}
+
+// cacheServerHandler atende GET /v1/entries?l=<idioma>&k=<chave>... com as
+// entradas encontradas e PUT /v1/entries?l=<idioma> com um objeto
+// chave -> entrada.
+func cacheServerHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/health", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		n := storage.count()
+		mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "entries": n, "version": _VERSION_})
+	})
+	mux.HandleFunc("/v1/entries", func(w http.ResponseWriter, r *http.Request) {
+		lang := r.URL.Query().Get("l")
+		if lang == "" {
+			http.Error(w, "missing l", http.StatusBadRequest)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			found := make(map[string]CacheEntry)
+			mu.Lock()
+			for _, key := range r.URL.Query()["k"] {
+				if e, ok := storage.get(lang, key); ok {
+					found[key] = e
+					storage.touch(lang, key, time.Now())
+				}
+			}
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(found)
+		case http.MethodPut:
+			entries := make(map[string]CacheEntry)
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 8<<20)).Decode(&entries); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			mu.Lock()
+			storage.batch(func() {
+				for key, e := range entries {
+					if key != "" {
+						mergeRemoteEntry(lang, key, e)
+					}
+				}
+			})
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	if token == "" {
+		return mux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// --- TMX ---
+
+// tmxDoc cobre o subconjunto do TMX 1.4 que o cache usa: uma <tu> por texto
+// de origem, com uma <tuv> para a origem e uma para cada idioma traduzido.
+type tmxDoc struct {
+	XMLName xml.Name  `xml:"tmx"`
+	Version string    `xml:"version,attr"`
+	Header  tmxHeader `xml:"header"`
+	Units   []tmxTU   `xml:"body>tu"`
+}
+
+type tmxHeader struct {
+	CreationTool        string `xml:"creationtool,attr"`
+	CreationToolVersion string `xml:"creationtoolversion,attr"`
+	SegType             string `xml:"segtype,attr"`
+	OTMF                string `xml:"o-tmf,attr"`
+	AdminLang           string `xml:"adminlang,attr"`
+	SrcLang             string `xml:"srclang,attr"`
+	DataType            string `xml:"datatype,attr"`
+	CreationDate        string `xml:"creationdate,attr,omitempty"`
+}
+
+type tmxTU struct {
+	SrcLang       string    `xml:"srclang,attr,omitempty"`
+	LastUsageDate string    `xml:"lastusagedate,attr,omitempty"`
+	Props         []tmxProp `xml:"prop"`
+	Variants      []tmxTUV  `xml:"tuv"`
+}
+
+type tmxProp struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type tmxTUV struct {
+	Lang          string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	LastUsageDate string `xml:"lastusagedate,attr,omitempty"`
+	ChangeDate    string `xml:"changedate,attr,omitempty"`
+	CreationDate  string `xml:"creationdate,attr,omitempty"`
+	Seg           string `xml:"seg"`
+}
+
+const tmxTimeLayout = "20060102T150405Z"
+
+// tmxLang converte "pt_BR" em "pt-BR"; cacheLang faz o caminho inverso.
+func tmxLang(lang string) string {
+	return strings.ReplaceAll(lang, "_", "-")
+}
+
+// Subetiquetas de escrita do BCP 47 que correspondem a um código do chili:
+// zh-Hans (e zh-Hans-SG) é zh_CN, zh-Hant (e zh-Hant-HK) é zh_TW.
+var tmxScriptLangs = map[string]string{
+	"zh_hans": "zh_CN",
+	"zh_hant": "zh_TW",
+}
+
+func cacheLang(lang string) string {
+	parts := strings.Split(strings.ReplaceAll(lang, "-", "_"), "_")
+	parts[0] = strings.ToLower(parts[0])
+	if len(parts) > 1 && len(parts[1]) == 4 {
+		if code, ok := tmxScriptLangs[parts[0]+"_"+strings.ToLower(parts[1])]; ok {
+			return code
+		}
+		parts[1] = strings.ToUpper(parts[1][:1]) + strings.ToLower(parts[1][1:])
+	}
+	for i := 1; i < len(parts); i++ {
+		if len(parts[i]) == 2 {
+			parts[i] = strings.ToUpper(parts[i])
+		}
+	}
+	return strings.Join(parts, "_")
+}
+
+func tmxTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(tmxTimeLayout)
+}
+
+func (tu tmxTU) prop(name string) string {
+	for _, p := range tu.Props {
+		if p.Type == name {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+func cacheExport(args []string) int {
+	fs := newCacheFlagSet("export")
+	filter := addCacheFilterFlags(fs)
+	format := fs.String("format", "tmx", T("Formato de saída (tmx)"))
+	output := fs.StringP("output", "o", "", T("Arquivo de saída (padrão: saída padrão)"))
+	srcLang := fs.String("source-lang", "", T("Idioma gravado na origem das entradas com origem 'auto' (ex: en)"))
+	if !parseCacheFlags(fs, args) {
+		return 1
+	}
+	if *format != "tmx" {
+		fmt.Fprintf(os.Stderr, "%s %s: %s\n", red(T("ERRO:")), white(T("Formato não suportado")), *format)
+		return 1
+	}
+	if _, ok := readCacheEntries(); !ok {
+		return 1
+	}
+
+	doc := buildTMX(filter, *srcLang)
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s %v\n", red(T("ERRO:")), err)
+		return 1
+	}
+	data = append([]byte(xml.Header), append(data, '\n')...)
+	if *output == "" {
+		os.Stdout.Write(data)
+		return 0
+	}
+	if err := writeFileAtomic(*output, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "%s %s: %v\n", red(T("ERRO:")), white(T("Falha ao gravar")), err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "%s %d %s %s\n", green("✔"), len(doc.Units), T("unidades exportadas para"), cyan(*output))
+	return 0
+}
+
+// buildTMX agrupa as entradas por motor, origem e texto: cada grupo vira uma
+// <tu> com as traduções de todos os idiomas. As migradas do esquema 1 ficam
+// de fora, porque o texto original delas não é conhecido.
+func buildTMX(filter *cacheFilter, srcLang string) tmxDoc {
+	doc := tmxDoc{
+		Version: "1.4",
+		Header: tmxHeader{
+			CreationTool: _APP_, CreationToolVersion: _VERSION_, SegType: "sentence", OTMF: cacheFormat,
+			AdminLang: "en", SrcLang: "*all*", DataType: "plaintext", CreationDate: tmxTime(time.Now()),
+		},
+	}
+	units := make(map[string]*tmxTU)
+	var order []string
+	cacheEach(filter, func(lang, key string, e CacheEntry) {
+		if e.Source == "" {
+			return
+		}
+		from, engineKey := firstNonEmpty(e.From, "auto"), firstNonEmpty(e.Engine, "legacy")
+		id := engineKey + "|" + from + "|" + e.Source
+		tu, ok := units[id]
+		if !ok {
+			tuvLang := tmxLang(from)
+			if from == "auto" {
+				tuvLang = firstNonEmpty(srcLang, "und")
+			}
+			tu = &tmxTU{
+				SrcLang:  tuvLang,
+				Props:    []tmxProp{{"x-chili-engine", engineKey}, {"x-chili-from", from}},
+				Variants: []tmxTUV{{Lang: tuvLang, Seg: e.Source}},
+			}
+			units[id] = tu
+			order = append(order, id)
+		}
+		used := tmxTime(e.LastUsed)
+		tu.Variants = append(tu.Variants, tmxTUV{Lang: tmxLang(lang), LastUsageDate: used, Seg: e.Value})
+		if used > tu.LastUsageDate {
+			tu.LastUsageDate = used
+		}
+	})
+	sort.Strings(order)
+	for _, id := range order {
+		doc.Units = append(doc.Units, *units[id])
+	}
+	return doc
+}
+
+func cacheImport(args []string) int {
+	fs := newCacheFlagSet("import")
+	filter := addCacheFilterFlags(fs)
+	overwrite := fs.Bool("overwrite", false, T("Substitui entradas que já existem no cache"))
+	human := fs.Bool("human", false, T("Grava as memórias de outras ferramentas como traduções humanas"))
+	if !parseCacheFlags(fs, args) {
+		return 1
+	}
+	if fs.NArg() == 0 {
+		cacheUsage()
+		return 1
+	}
+	loadCache()
+	defer saveCache()
+
+	status := 0
+	for _, path := range fs.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", red(T("ERRO:")), err)
+			status = 1
+			continue
+		}
+		var doc tmxDoc
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			fmt.Fprintf(os.Stderr, "%s %s %s: %v\n", red(T("ERRO:")), white(T("TMX inválido")), yellow(path), err)
+			status = 1
+			continue
+		}
+		var added, skipped, noLang int
+		storage.batch(func() { added, skipped, noLang = importTMX(doc, filter, *overwrite, *human) })
+		fmt.Printf("%s %s: %d %s, %d %s", green("✔"), cyan(path), added, T("importadas"), skipped, T("já existentes"))
+		if noLang > 0 {
+			fmt.Printf(", %d %s", noLang, yellow(T("unidades sem xml:lang ignoradas")))
+		}
+		fmt.Println()
+	}
+	return status
+}
+
+// tmxEngine é o motor das memórias importadas de outras ferramentas.
+const tmxEngine = "tmx"
+
+// importTMX grava as traduções de cada <tu>. A origem é a <tuv> no idioma
+// srclang da unidade (ou do cabeçalho); sem ela, a primeira. O motor e a
+// origem exportados pelo próprio chili-tradutor-go são preservados; as
+// memórias de outras ferramentas entram sob o motor "tmx" (ou como
+// traduções humanas, com human) de origem auto, ou com o motor de -e e a
+// origem de -s. Unidades com alguma <tuv> sem xml:lang são ignoradas e
+// contadas em noLang.
+func importTMX(doc tmxDoc, filter *cacheFilter, overwrite, human bool) (added, skipped, noLang int) {
+	foreign := tmxEngine
+	if human {
+		foreign = humanEngine
+	}
+units:
+	for _, tu := range doc.Units {
+		if len(tu.Variants) < 2 {
+			continue
+		}
+		for _, v := range tu.Variants {
+			if strings.TrimSpace(v.Lang) == "" {
+				noLang++
+				continue units
+			}
+		}
+		srcLang := firstNonEmpty(tu.SrcLang, doc.Header.SrcLang)
+		src := 0
+		for i, v := range tu.Variants {
+			if strings.EqualFold(v.Lang, srcLang) {
+				src = i
+				break
+			}
+		}
+		source := normalizeSource(tu.Variants[src].Seg)
+		if source == "" {
+			continue
+		}
+		engineKey := firstNonEmpty(filter.engine, tu.prop("x-chili-engine"), foreign)
+		from := firstNonEmpty(filter.from, tu.prop("x-chili-from"), "auto")
+		key := cacheKey(engineKey, from, source)
+		if engineKey == "legacy" {
+			key = legacyCacheKey(source)
+		}
+		for i, v := range tu.Variants {
+			lang := cacheLang(v.Lang)
+			if i == src || v.Seg == "" || (filter.langs != "" && !containsString(strings.Split(filter.langs, ","), lang)) {
+				continue
+			}
+			if _, exists := storage.get(lang, key); exists && !overwrite {
+				skipped++
+				continue
+			}
+			used := time.Now()
+			for _, d := range []string{v.LastUsageDate, v.ChangeDate, v.CreationDate, tu.LastUsageDate} {
+				if t, err := time.Parse(tmxTimeLayout, d); err == nil {
+					used = t
+					break
+				}
+			}
+			e := CacheEntry{Value: v.Seg, LastUsed: used, Source: source, From: from, Engine: engineKey, Human: engineKey == humanEngine}
+			storage.put(lang, key, e)
+			added++
 		}
-		if i%5 == 0 || i == len(lines)-1 { updateProgress(lang, i+1, len(lines), "HTML") }
 	}
-	ext := filepath.Ext(inputPath)
-	base := strings.TrimSuffix(filepath.Base(inputPath), ext)
-	outFile := filepath.Join("html", fmt.Sprintf("%s-%s%s", base, lang, ext))
-	os.WriteFile(outFile, []byte(strings.Join(translatedLines, "\n")), 0644)
-	updateProgress(lang, len(lines), len(lines), "OK")
+	return added, skipped, noLang
 }
 
-func translateMarkdown(inputPath, lang string) {
-	content, _ := os.ReadFile(inputPath)
-	lines := strings.Split(string(content), "\n")
-	var translatedLines []string
-	inCodeBlock := false
-	ext := filepath.Ext(inputPath)
-	base := strings.TrimSuffix(filepath.Base(inputPath), ext)
-	outFile := filepath.Join("doc", fmt.Sprintf("%s-%s%s", base, lang, ext))
+// --- PO ---
+
+// poEntry é uma mensagem de um catálogo PO; Str tem um elemento por forma
+// (msgstr ou msgstr[N]). Comments guarda, na ordem, as linhas de comentário
+// que não são flags ("# ", "#.", "#:", "#|"); raw guarda os pedaços de cada
+// string como estavam no arquivo, para regravar sem mudar a quebra de linha
+// o que não foi alterado.
+type poEntry struct {
+	Comments []string
+	Flags    []string
+	Context  string
+	ID       string
+	Plural   string
+	Str      []string
+	Obsolete bool
+	raw      map[string][]string
+	// flagsAt guarda onde a linha "#," estava entre os comentários lidos
+	// (posição + 1); 0 a grava na posição do gettext, antes dos "#|".
+	flagsAt int
+}
+
+func (e *poEntry) hasFlag(flag string) bool {
+	return containsString(e.Flags, flag)
+}
+
+func (e *poEntry) setFlag(flag string, on bool) {
+	if on == e.hasFlag(flag) {
+		return
+	}
+	if on {
+		e.Flags = append(e.Flags, flag)
+		return
+	}
+	var kept []string
+	for _, f := range e.Flags {
+		if f != flag {
+			kept = append(kept, f)
+		}
+	}
+	e.Flags = kept
+}
+
+func (e *poEntry) translated() bool {
+	if len(e.Str) == 0 {
+		return false
+	}
+	for _, s := range e.Str {
+		if s == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// poCatalog é um arquivo PO/POT. Header é a entrada de msgid vazio (com os
+// comentários do topo do arquivo); Width é a largura usada ao quebrar
+// strings novas: 79 como no gettext, ou 0 em arquivos gerados com --no-wrap.
+// Trailer são os comentários depois da última mensagem.
+type poCatalog struct {
+	Header  *poEntry
+	Entries []*poEntry
+	Width   int
+	Trailer []string
+}
+
+// HeaderValue devolve um campo do cabeçalho ("Language", "Plural-Forms").
+func (po *poCatalog) HeaderValue(key string) string {
+	if po.Header == nil || len(po.Header.Str) == 0 {
+		return ""
+	}
+	for _, line := range strings.Split(po.Header.Str[0], "\n") {
+		if k, v, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(k) == key {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// SetHeaderValue troca o campo no lugar ou o acrescenta ao final.
+func (po *poCatalog) SetHeaderValue(key, value string) {
+	if po.Header == nil {
+		po.Header = &poEntry{Str: []string{""}}
+	}
+	if len(po.Header.Str) == 0 {
+		po.Header.Str = []string{""}
+	}
+	lines := strings.SplitAfter(po.Header.Str[0], "\n")
 	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "```") {
-			inCodeBlock = !inCodeBlock
-			translatedLines = append(translatedLines, line)
+		if k, _, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(k) == key {
+			lines[i] = key + ": " + value + "\n"
+			po.Header.Str[0] = strings.Join(lines, "")
+			return
+		}
+	}
+	po.Header.Str[0] = strings.TrimSuffix(strings.Join(lines, ""), "\n")
+	if po.Header.Str[0] != "" {
+		po.Header.Str[0] += "\n"
+	}
+	po.Header.Str[0] += key + ": " + value + "\n"
+}
+
+// poKey identifica uma mensagem no catálogo, como no .mo: msgctxt\x04msgid.
+func poKey(e *poEntry) string {
+	if e.Context != "" {
+		return e.Context + "\x04" + e.ID
+	}
+	return e.ID
+}
+
+// poMergeStats resume o que mergePO fez com as traduções existentes.
+type poMergeStats struct {
+	Kept, Fuzzy, Obsolete int
+}
+
+// mergePO faz o que o msgmerge faz: po traz as mensagens atuais do POT e old
+// é o catálogo já traduzido. Traduções de mensagens que continuam no POT são
+// mantidas; as de msgid alterado são aproveitadas como fuzzy (com o msgid
+// antigo em "#|"); as que saíram do POT viram obsoletas. Traduções com um
+// número de formas de plural diferente de nplurals não são aproveitadas.
+func mergePO(po, old *poCatalog, nplurals int) poMergeStats {
+	var stats poMergeStats
+	exact := make(map[string]*poEntry)
+	for _, e := range old.Entries {
+		if k := poKey(e); exact[k] == nil || exact[k].Obsolete {
+			exact[k] = e
+		}
+	}
+	usable := func(o, e *poEntry) bool {
+		if !o.translated() || (o.Plural == "") != (e.Plural == "") {
+			return false
+		}
+		return e.Plural == "" || len(o.Str) == nplurals
+	}
+	used := make(map[*poEntry]bool)
+	var missing []*poEntry
+	for _, e := range po.Entries {
+		o := exact[poKey(e)]
+		if o != nil {
+			used[o] = true // mesma mensagem: nunca vira obsoleta
+		}
+		if o == nil || !usable(o, e) {
+			missing = append(missing, e)
 			continue
 		}
-		if inCodeBlock || trimmed == "" {
-			translatedLines = append(translatedLines, line)
+		e.Str = o.Str
+		e.Comments, e.flagsAt = append(poTranslatorComments(o), poExtractedComments(e)...), 0
+		if o.hasFlag("fuzzy") {
+			e.setFlag("fuzzy", true)
+		}
+		stats.Kept++
+	}
+
+	// Mensagens de msgid alterado: a antiga mais parecida que não tenha
+	// sido usada, com o mesmo limiar de semelhança do msgmerge.
+	var candidates []*poEntry
+	for _, o := range old.Entries {
+		if !used[o] && !o.Obsolete && o.translated() {
+			candidates = append(candidates, o)
+		}
+	}
+	for _, e := range missing {
+		var best *poEntry
+		bestScore := 0.6
+		for _, o := range candidates {
+			if used[o] || o.Context != e.Context || !usable(o, e) {
+				continue
+			}
+			if score := similarity(o.ID, e.ID); score >= bestScore {
+				best, bestScore = o, score
+			}
+		}
+		if best == nil {
 			continue
 		}
-		rePrefix := regexp.MustCompile(`^(\s*#+\s*|\s*[\*\-\+]\s*|\s*\d+\.\s*)`)
-		prefix, textToTranslate := "", line
-		if loc := rePrefix.FindStringIndex(line); loc != nil {
-			prefix = line[loc[0]:loc[1]]
-			textToTranslate = line[loc[1]:]
+		used[best] = true
+		e.Str = best.Str
+		e.Comments, e.flagsAt = append(poTranslatorComments(best), poExtractedComments(e)...), 0
+		e.Comments = append(e.Comments, poPrevious("msgid", best.ID)...)
+		if best.Plural != "" {
+			e.Comments = append(e.Comments, poPrevious("msgid_plural", best.Plural)...)
 		}
-		translated := callUniversalTranslator(textToTranslate, lang)
-		translatedLines = append(translatedLines, prefix+translated)
-		if i%10 == 0 || i == len(lines)-1 { updateProgress(lang, i+1, len(lines), "MD") }
+		e.setFlag("fuzzy", true)
+		stats.Fuzzy++
 	}
-	os.WriteFile(outFile, []byte(strings.Join(translatedLines, "\n")), 0644)
-	updateProgress(lang, len(lines), len(lines), "OK")
+
+	for _, o := range old.Entries {
+		if used[o] || !o.translated() {
+			continue
+		}
+		if !o.Obsolete {
+			stats.Obsolete++
+		}
+		o.Obsolete = true
+		o.Comments, o.flagsAt = poTranslatorComments(o), 0
+		po.Entries = append(po.Entries, o)
+	}
+	return stats
 }
 
-func translatePlaintext(inputPath, lang string) {
-	content, _ := os.ReadFile(inputPath)
-	lines := strings.Split(string(content), "\n")
-	var translatedLines []string
-	ext := filepath.Ext(inputPath)
-	if ext == "" { ext = ".txt" }
-	base := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
-	outFile := filepath.Join("txt", fmt.Sprintf("%s-%s%s", base, lang, ext))
-	for i, line := range lines {
-		if strings.TrimSpace(line) == "" {
-			translatedLines = append(translatedLines, line)
-		} else {
-			translated := callUniversalTranslator(line, lang)
-			translatedLines = append(translatedLines, translated)
+// poTranslatorComments devolve os comentários do tradutor ("# "), que
+// acompanham a tradução; referências e comentários extraídos vêm do POT.
+func poTranslatorComments(e *poEntry) []string {
+	var out []string
+	for _, c := range e.Comments {
+		if c == "#" || strings.HasPrefix(c, "# ") {
+			out = append(out, c)
 		}
-		if i%10 == 0 || i == len(lines)-1 { updateProgress(lang, i+1, len(lines), "TXT") }
 	}
-	os.WriteFile(outFile, []byte(strings.Join(translatedLines, "\n")), 0644)
-	updateProgress(lang, len(lines), len(lines), "OK")
+	return out
 }
 
-func translateFile(baseName, lang string) {
-	cleanBase := strings.TrimSuffix(baseName, filepath.Ext(baseName))
-	poTmp := filepath.Join("pot", fmt.Sprintf("%s-temp-%s.po", cleanBase, lang))
-	poFinal := filepath.Join("pot", fmt.Sprintf("%s-%s.po", cleanBase, lang))
-	stampPotHeader(poTmp, lang)
-	file, _ := os.Open(poTmp)
-	defer file.Close()
-	output, _ := os.Create(poFinal)
-	defer output.Close()
-	var lines []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() { lines = append(lines, scanner.Text()) }
-	totalMsgids := 0
-	for _, l := range lines {
-		if strings.HasPrefix(l, "msgid ") && l != `msgid ""` { totalMsgids++ }
-	}
-	current := 0
-	var isMsgid bool
-	var msgidLines []string
-	for _, line := range lines {
-		if strings.HasPrefix(line, "msgid ") && line != `msgid ""` {
-			isMsgid = true
-			msgidLines = []string{strings.TrimPrefix(line, "msgid ")}
-		} else if strings.HasPrefix(line, "msgstr ") && isMsgid {
-			current++
-			updateProgress(lang, current, totalMsgids, "PO")
-			translated := callUniversalTranslator(strings.Trim(strings.Join(msgidLines, " "), `"`), lang)
-			if translated == "" { translated = strings.Trim(strings.Join(msgidLines, " "), `"`) }
-			fmt.Fprintf(output, "msgid %s\nmsgstr \"%s\"\n", strings.Join(msgidLines, "\n"), translated)
-			isMsgid = false
-		} else if isMsgid {
-			msgidLines = append(msgidLines, line)
-		} else { fmt.Fprintln(output, line) }
+func poExtractedComments(e *poEntry) []string {
+	var out []string
+	for _, c := range e.Comments {
+		if c != "#" && !strings.HasPrefix(c, "# ") && !strings.HasPrefix(c, "#|") {
+			out = append(out, c)
+		}
 	}
-	os.Remove(poTmp)
-	updateProgress(lang, totalMsgids, totalMsgids, "OK")
+	return out
 }
 
-func translateJSON(path, lang string) {
-	ext := strings.ToLower(filepath.Ext(path))
-	targetDir := "json"
-	if ext == ".yaml" || ext == ".yml" { targetDir = "yml" }
-	data, _ := os.ReadFile(path)
-	var obj map[string]interface{}
-	json.Unmarshal(data, &obj)
-	translateMap(obj, lang)
-	out, _ := json.MarshalIndent(obj, "", "  ")
-	outFile := filepath.Join(targetDir, fmt.Sprintf("%s-%s%s", strings.TrimSuffix(filepath.Base(path), ext), lang, ext))
-	os.WriteFile(outFile, out, 0644)
-	updateProgress(lang, 100, 100, strings.ToUpper(targetDir))
+// poPrevious monta as linhas "#| msgid ..." com o texto anterior da mensagem.
+func poPrevious(keyword, s string) []string {
+	chunks := poWrap(s, 79, len("#| ")+len(keyword)+1)
+	lines := []string{"#| " + keyword + " " + poQuote(chunks[0])}
+	for _, c := range chunks[1:] {
+		lines = append(lines, "#| "+poQuote(c))
+	}
+	return lines
 }
 
-func translateMap(m map[string]interface{}, lang string) {
-	for k, v := range m {
-		if val, ok := v.(string); ok {
-			m[k] = callUniversalTranslator(val, lang)
-		} else if valMap, ok := v.(map[string]interface{}); ok {
-			translateMap(valMap, lang)
+// similarity vai de 0 a 1 e mede quão parecidas são duas strings como o
+// fstrcmp do msgmerge: o dobro dos caracteres em comum (maior subsequência
+// comum) sobre a soma dos tamanhos.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	total := len(ra) + len(rb)
+	if total == 0 {
+		return 1
+	}
+	if len(ra) < len(rb) {
+		ra, rb = rb, ra
+	}
+	if float64(2*len(rb))/float64(total) < 0.6 {
+		return 0
+	}
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			switch {
+			case ra[i-1] == rb[j-1]:
+				cur[j] = prev[j-1] + 1
+			case prev[j] >= cur[j-1]:
+				cur[j] = prev[j]
+			default:
+				cur[j] = cur[j-1]
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return float64(2*prev[len(rb)]) / float64(total)
+}
+
+var rePOKeyword = regexp.MustCompile(`^(msgctxt|msgid_plural|msgid|msgstr(?:\[(\d+)\])?)\s+(".*")$`)
+
+func readPOFile(path string) (*poCatalog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parsePO(f)
+}
+
+// parsePO lê um catálogo PO, desfazendo o escape das strings e juntando as
+// linhas de continuação.
+func parsePO(r io.Reader) (*poCatalog, error) {
+	po := &poCatalog{Width: 79}
+	var cur *poEntry
+	var field *string
+	var rawKey string
+	seenStr := false
+	flush := func() {
+		if cur == nil {
+			return
+		}
+		if cur.ID == "" && cur.Context == "" && !cur.Obsolete && po.Header == nil && len(po.Entries) == 0 {
+			po.Header = cur
+		} else if cur.ID != "" || cur.Context != "" || len(cur.Str) > 0 {
+			po.Entries = append(po.Entries, cur)
+		}
+		cur, field, rawKey, seenStr = nil, nil, "", false
+	}
+	start := func() {
+		if cur == nil || seenStr {
+			flush()
+			cur = &poEntry{raw: make(map[string][]string)}
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		obsolete := false
+		if rest, ok := strings.CutPrefix(line, "#~"); ok && !strings.HasPrefix(rest, "|") {
+			line, obsolete = strings.TrimSpace(rest), true
+		}
+		if len(line) > 79 && strings.HasSuffix(line, `"`) {
+			po.Width = 0
+		}
+		switch {
+		case line == "":
+			// Comentários soltos seguidos de linha em branco ficam com a
+			// próxima mensagem, como no gettext.
+			if !obsolete && (cur == nil || len(cur.raw) > 0) {
+				flush()
+			}
+			continue
+		case strings.HasPrefix(line, "#,"):
+			start()
+			if cur.flagsAt == 0 {
+				cur.flagsAt = len(cur.Comments) + 1
+			}
+			for _, f := range strings.Split(line[2:], ",") {
+				if f = strings.TrimSpace(f); f != "" {
+					cur.Flags = append(cur.Flags, f)
+				}
+			}
+			continue
+		case strings.HasPrefix(line, "#"):
+			start()
+			cur.Comments = append(cur.Comments, line)
+			continue
+		}
+
+		value := line
+		if m := rePOKeyword.FindStringSubmatch(line); m != nil {
+			if m[1] == "msgctxt" || m[1] == "msgid" {
+				if m[1] == "msgctxt" || cur == nil || cur.Context == "" || cur.ID != "" || seenStr {
+					start()
+				}
+			} else if cur == nil {
+				return nil, fmt.Errorf("%s %d: %s", T("linha"), n, line)
+			}
+			cur.Obsolete = cur.Obsolete || obsolete
+			rawKey = m[1]
+			switch {
+			case m[1] == "msgctxt":
+				field = &cur.Context
+			case m[1] == "msgid":
+				field = &cur.ID
+			case m[1] == "msgid_plural":
+				field = &cur.Plural
+			default:
+				idx := 0
+				if m[2] != "" {
+					idx, _ = strconv.Atoi(m[2])
+				}
+				for len(cur.Str) <= idx {
+					cur.Str = append(cur.Str, "")
+				}
+				field = &cur.Str[idx]
+				rawKey = fmt.Sprintf("msgstr[%d]", idx)
+				seenStr = true
+			}
+			value = m[3]
+		} else if !strings.HasPrefix(line, `"`) || field == nil {
+			return nil, fmt.Errorf("%s %d: %s", T("linha"), n, line)
+		}
+		s, err := poUnquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s %d: %v", T("linha"), n, err)
+		}
+		*field += s
+		cur.raw[rawKey] = append(cur.raw[rawKey], s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if cur != nil && len(cur.raw) == 0 && (po.Header != nil || len(po.Entries) > 0) {
+		po.Trailer = cur.Comments
+		if len(cur.Flags) > 0 {
+			po.Trailer = append(po.Trailer, "#, "+strings.Join(cur.Flags, ", "))
+		}
+		cur = nil
+	}
+	flush()
+	return po, nil
+}
+
+// poUnquote desfaz as aspas e os escapes de uma string PO. Diferente do
+// strconv.Unquote, aceita os escapes do C que o gettext aceita (\', \?, octal
+// e \x de qualquer tamanho) e não interpreta \u.
+func poUnquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("%s: %s", T("string sem aspas"), s)
+	}
+	s = s[1 : len(s)-1]
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '"' {
+			return "", fmt.Errorf("%s: %s", T("aspas sem escape"), s)
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", fmt.Errorf("%s: %s", T("escape incompleto"), s)
+		}
+		switch c = s[i]; c {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		case '\\', '"', '\'', '?':
+			b.WriteByte(c)
+		case 'x':
+			j := i + 1
+			for j < len(s) && strings.IndexByte("0123456789abcdefABCDEF", s[j]) >= 0 {
+				j++
+			}
+			if j == i+1 {
+				return "", fmt.Errorf("%s: %s", T("escape inválido"), s)
+			}
+			v, _ := strconv.ParseUint(s[i+1:j], 16, 64)
+			b.WriteByte(byte(v))
+			i = j - 1
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			j := i
+			for j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7' {
+				j++
+			}
+			v, _ := strconv.ParseUint(s[i:j], 8, 64)
+			b.WriteByte(byte(v))
+			i = j - 1
+		default:
+			return "", fmt.Errorf("%s \\%c: %s", T("escape inválido"), c, s)
+		}
+	}
+	return b.String(), nil
+}
+
+func writePOFile(path string, po *poCatalog) error {
+	var buf bytes.Buffer
+	po.WriteTo(&buf)
+	return writeFileAtomic(path, buf.Bytes(), 0644)
+}
+
+// WriteTo serializa o catálogo na ordem do gettext: comentários, flags,
+// comentários "#|", msgctxt, msgid, msgid_plural e msgstr.
+func (po *poCatalog) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	entries := po.Entries
+	if po.Header != nil {
+		entries = append([]*poEntry{po.Header}, entries...)
+	}
+	for i, e := range entries {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		flags := ""
+		if len(e.Flags) > 0 {
+			flags = "#, " + strings.Join(e.Flags, ", ") + "\n"
+		}
+		var previous []string
+		for n, c := range e.Comments {
+			if n+1 == e.flagsAt {
+				buf.WriteString(flags)
+				flags = ""
+			}
+			if strings.HasPrefix(c, "#|") || strings.HasPrefix(c, "#~|") {
+				previous = append(previous, c)
+				continue
+			}
+			buf.WriteString(c + "\n")
+		}
+		buf.WriteString(flags)
+		for _, c := range previous {
+			buf.WriteString(c + "\n")
+		}
+		prefix := ""
+		if e.Obsolete {
+			prefix = "#~ "
+		}
+		if e.Context != "" || len(e.raw["msgctxt"]) > 0 {
+			po.writeString(&buf, prefix, "msgctxt", e.Context, e.raw["msgctxt"])
+		}
+		po.writeString(&buf, prefix, "msgid", e.ID, e.raw["msgid"])
+		if e.Plural != "" {
+			po.writeString(&buf, prefix, "msgid_plural", e.Plural, e.raw["msgid_plural"])
+			strs := e.Str
+			if len(strs) == 0 {
+				strs = []string{"", ""}
+			}
+			for n, s := range strs {
+				key := fmt.Sprintf("msgstr[%d]", n)
+				po.writeString(&buf, prefix, key, s, e.raw[key])
+			}
+		} else {
+			s := ""
+			if len(e.Str) > 0 {
+				s = e.Str[0]
+			}
+			po.writeString(&buf, prefix, "msgstr", s, e.raw["msgstr[0]"])
+		}
+	}
+	if len(po.Trailer) > 0 {
+		if len(entries) > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(strings.Join(po.Trailer, "\n") + "\n")
+	}
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// writeString grava uma string: com os pedaços originais se ela não mudou,
+// senão quebrada como o gettext faz (após cada \n e em espaços, até Width).
+func (po *poCatalog) writeString(buf *bytes.Buffer, prefix, keyword, s string, raw []string) {
+	chunks := raw
+	if strings.Join(raw, "") != s || len(raw) == 0 {
+		chunks = poWrap(s, po.Width, len(prefix)+len(keyword)+1)
+	}
+	buf.WriteString(prefix + keyword + " " + poQuote(chunks[0]) + "\n")
+	for _, c := range chunks[1:] {
+		buf.WriteString(prefix + poQuote(c) + "\n")
+	}
+}
+
+func poWrap(s string, width, indent int) []string {
+	if s == "" {
+		return []string{""}
+	}
+	var lines []string
+	for _, part := range strings.SplitAfter(s, "\n") {
+		if part == "" {
+			continue
+		}
+		if width <= 0 {
+			lines = append(lines, part)
+			continue
+		}
+		for len(poQuote(part)) > width {
+			cut := -1
+			for i := 1; i < len(part); i++ {
+				if part[i-1] == ' ' && len(poQuote(part[:i])) <= width {
+					cut = i
+				}
+			}
+			if cut <= 0 {
+				break
+			}
+			lines = append(lines, part[:cut])
+			part = part[cut:]
+		}
+		lines = append(lines, part)
+	}
+	if width <= 0 {
+		if len(lines) == 1 {
+			return lines
+		}
+		return append([]string{""}, lines...)
+	}
+	// Como o gettext: uma linha só se couber inteira e não tiver \n no meio.
+	if len(lines) == 1 && indent+len(poQuote(s)) <= width {
+		return lines
+	}
+	return append([]string{""}, lines...)
+}
+
+// poQuote devolve a string entre aspas com os escapes do C que o gettext usa.
+func poQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\a':
+			b.WriteString(`\a`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\v':
+			b.WriteString(`\v`)
+		default:
+			b.WriteRune(r)
 		}
 	}
+	b.WriteByte('"')
+	return b.String()
 }
 
-// --- UTILITÁRIOS ---
+// --- MO ---
 
-func updateProgress(lang string, current, total int, suffix string) {
-	if quietFlag || total == 0 { return }
-	muConsole.Lock()
-	defer muConsole.Unlock()
-	pos := langPositions[lang]
-	if pos == 0 { return }
-	percent := (current * 100) / total
-	width := 40
-	filled := (percent * width) / 100
-	bar := blue(strings.Repeat("░", filled)) + strings.Repeat(" ", width-filled)
-	langStr := fmt.Sprintf("%-7s", lang)
-	fmt.Printf("\033[%dA\r\033[K    → %s %s [%s] %3d%% %-5s\033[%dB", pos, blue("→"), cyan(langStr), bar, percent, cyan(suffix), pos)
-}
+const moMagic = 0x950412de
 
-func prepareMsginit(base, lang string) {
-	cleanBase := strings.TrimSuffix(base, filepath.Ext(base))
-	pot := filepath.Join("pot", cleanBase+".pot")
-	po := filepath.Join("pot", fmt.Sprintf("%s-temp-%s.po", cleanBase, lang))
-	os.Remove(po)
-	execCommand("msginit", "--no-translator", "-l", lang, "-i", pot, "-o", po).Run()
+// moMessage é uma mensagem pronta para o .mo: key é msgctxt\x04msgid (mais
+// \x00msgid_plural) e value as formas do msgstr separadas por \x00.
+type moMessage struct {
+	key, value string
 }
 
-func protectVariables(text string) (string, map[string]string) {
-	re := regexp.MustCompile(`(\$\{[A-Za-z0-9_.]+\}|\$[A-Za-z0-9_.]+|%[a-z]|!\[.*?\]\(.*?\)|\[.*?\]\(.*?\)|https?://[^\s]+)`)
-	placeholders := make(map[string]string)
-	protected := text
-	matches := re.FindAllString(text, -1)
-	for i, match := range matches {
-		p := fmt.Sprintf("CHILI_REF_%d_CHILI", i)
-		placeholders[p] = match
-		protected = strings.Replace(protected, match, p, 1)
+// compileMO valida o catálogo e devolve as mensagens que vão para o .mo, na
+// ordem do msgfmt. Como ele, deixa de fora as obsoletas, as sem tradução e,
+// sem includeFuzzy, as fuzzy (o cabeçalho entra sempre). Cada mensagem
+// inválida é descartada e devolvida como um erro.
+func compileMO(po *poCatalog, includeFuzzy bool) ([]moMessage, []error) {
+	var msgs []moMessage
+	var errs []error
+	rule := moPluralRule{}
+	if po.Header != nil && len(po.Header.Str) > 0 {
+		// O msgfmt tira o POT-Creation-Date, para que o .mo só mude
+		// quando as traduções mudam.
+		header := rePOTCreationDate.ReplaceAllString(po.Header.Str[0], "")
+		msgs = append(msgs, moMessage{"", header})
+		rule = parseMOPluralRule(po.HeaderValue("Plural-Forms"))
 	}
-	return protected, placeholders
+	seen := make(map[string]bool)
+	for _, e := range po.Entries {
+		if e.Obsolete || len(e.Str) == 0 || e.Str[0] == "" || (e.hasFlag("fuzzy") && !includeFuzzy) {
+			continue
+		}
+		if err := validateMOEntry(e, rule); err != nil {
+			errs = append(errs, fmt.Errorf("msgid %s: %v", poQuote(e.ID), err))
+			continue
+		}
+		key := poKey(e)
+		if seen[key] {
+			errs = append(errs, fmt.Errorf("msgid %s: %s", poQuote(e.ID), T("mensagem duplicada")))
+			continue
+		}
+		seen[key] = true
+		value := e.Str[0]
+		if e.Plural != "" {
+			key += "\x00" + e.Plural
+			value = strings.Join(e.Str, "\x00")
+		}
+		msgs = append(msgs, moMessage{key, value})
+	}
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].key < msgs[j].key })
+	return msgs, errs
 }
 
-func restoreVariables(text string, p map[string]string) string {
-	for k, v := range p { text = strings.Replace(text, k, v, -1) }
-	return text
-}
+var reCFormat = regexp.MustCompile(`%(?:(\d+)\$)?[-+ #0']*(?:\*|\d+)?(?:\.(?:\*|\d+))?(?:hh|h|ll|l|j|z|t|L)?([diouxXeEfFgGaAcspn%])`)
 
-func detectFileType(path string) (ext string, lang string, desc string) {
-	ext = strings.ToLower(filepath.Ext(path))
-	
-	// Caso 1: Arquivo sem extensão
-	if ext == "" {
-		detected, _ := getShebangInfo(path)
-		if detected != "" {
-			return "", detected, fmt.Sprintf(T("Script (%s)"), green(detected))
+// validateMOEntry faz as verificações do msgfmt -c que pegam os erros mais
+// comuns de tradução automática.
+func validateMOEntry(e *poEntry, rule moPluralRule) error {
+	if strings.ContainsRune(e.ID, 0) || strings.ContainsRune(e.Context, 0) {
+		return errors.New(T("caractere nulo no msgid"))
+	}
+	if e.Plural != "" {
+		if rule.n > 0 && len(e.Str) != rule.n {
+			return fmt.Errorf(T("%d formas de plural, o cabeçalho pede %d"), len(e.Str), rule.n)
 		}
-		return ".txt", "text", T("Texto Simples (sem extensão)")
+		for _, s := range e.Str {
+			if s == "" {
+				return errors.New(T("forma de plural sem tradução"))
+			}
+		}
+	} else if len(e.Str) != 1 {
+		return errors.New(T("msgstr[N] sem msgid_plural"))
 	}
-
-	// Caso 2: Man Pages ( .1 a .9 )
-	isMan, _ := regexp.MatchString(`^\.[1-9]$`, ext)
-	if isMan {
-		return ext, "manpage", fmt.Sprintf(T("Manual do Linux (%s)"), cyan(ext))
+	for i, s := range e.Str {
+		id := e.ID
+		if i > 0 {
+			id = e.Plural
+		}
+		if strings.HasPrefix(id, "\n") != strings.HasPrefix(s, "\n") {
+			return errors.New(T("msgid e msgstr não começam ambos com \\n"))
+		}
+		if strings.HasSuffix(id, "\n") != strings.HasSuffix(s, "\n") {
+			return errors.New(T("msgid e msgstr não terminam ambos com \\n"))
+		}
+		if strings.ContainsRune(s, 0) {
+			return errors.New(T("caractere nulo no msgstr"))
+		}
 	}
-
-	extMap := map[string]string{
-		".sh": "shell", ".py": "python", ".php": "php", ".c": "c",
-		".cpp": "c++", ".go": "go", ".pl": "perl", ".rb": "ruby",
-		".html": "html", ".htm": "html",
+	if !e.hasFlag("c-format") {
+		return nil
+	}
+	if e.Plural == "" {
+		if want, got := cFormatVerbs(e.ID), cFormatVerbs(e.Str[0]); want != got {
+			return fmt.Errorf(T("formato diferente: %q no msgid, %q no msgstr"), want, got)
+		}
+		return nil
+	}
+	// Como o msgfmt: cada forma é comparada com o msgid_plural; a forma que
+	// só vale para um número (o "1" do singular) pode omitir o número.
+	want := cFormatVerbs(e.Plural)
+	for i, str := range e.Str {
+		got := cFormatVerbs(str)
+		if got == want || (rule.single(i) && got == dropIntVerb(want)) {
+			continue
+		}
+		return fmt.Errorf(T("formato diferente: %q no msgid_plural, %q no msgstr[%d]"), want, got, i)
 	}
+	return nil
+}
 
-	if l, ok := extMap[ext]; ok {
-		return ext, l, fmt.Sprintf(T("Código %s (%s)"), ext, green(l))
+// cFormatVerbs devolve as conversões de uma string de formato do C, na
+// ordem em que são consumidas: a do texto ou, se todas forem posicionais
+// (%2$s), a das posições. %i conta como %d.
+func cFormatVerbs(s string) string {
+	type verb struct {
+		pos  int
+		conv string
+	}
+	var verbs []verb
+	positional := true
+	for _, m := range reCFormat.FindAllStringSubmatch(s, -1) {
+		if m[2] == "%" {
+			continue
+		}
+		pos, err := strconv.Atoi(m[1])
+		if err != nil {
+			positional = false
+		}
+		verbs = append(verbs, verb{pos, strings.Replace(m[2], "i", "d", 1)})
+	}
+	if positional {
+		sort.SliceStable(verbs, func(a, b int) bool { return verbs[a].pos < verbs[b].pos })
 	}
+	var out strings.Builder
+	for _, v := range verbs {
+		out.WriteString(v.conv)
+	}
+	return out.String()
+}
 
-	switch ext {
-	case ".md", ".markdown": return ext, "markdown", T("Markdown")
-	case ".txt": return ext, "text", T("Texto Simples")
-	case ".json": return ext, "json", T("JSON")
-	case ".yaml", ".yml": return ext, "yaml", T("YAML")
-	case ".pot": return ext, "gettext", T("Template POT")
+// dropIntVerb tira a primeira conversão inteira (o número do plural).
+func dropIntVerb(verbs string) string {
+	if i := strings.IndexAny(verbs, "diu"); i >= 0 {
+		return verbs[:i] + verbs[i+1:]
 	}
+	return verbs
+}
 
-	return ext, "shell", fmt.Sprintf(T("Arquivo %s"), ext)
+// moPluralRule é o que compileMO sabe do Plural-Forms do cabeçalho: o número
+// de formas e, se a expressão for uma das de pluralTable, a regra em Go.
+type moPluralRule struct {
+	n     int
+	forms *pluralForms
 }
 
-func getShebangInfo(path string) (string, string) {
-	f, err := os.Open(path)
-	if err != nil { return "", "" }
-	defer f.Close()
-	scanner := bufio.NewScanner(f)
-	if scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, "#!") {
-			lower := strings.ToLower(line)
-			switch {
-			case strings.Contains(lower, "python"): return "python", line
-			case strings.Contains(lower, "php"): return "php", line
-			case strings.Contains(lower, "perl"): return "perl", line
-			case strings.Contains(lower, "ruby"): return "ruby", line
-			case strings.Contains(lower, "node"): return "javascript", line
-			case strings.Contains(lower, "bash") || strings.Contains(lower, "sh"): return "shell", line
-			}
-			return "shell", line
+var (
+	rePOTCreationDate = regexp.MustCompile(`(?m)^POT-Creation-Date:.*\n`)
+	reNPlurals        = regexp.MustCompile(`nplurals\s*=\s*(\d+)`)
+	rePluralExpr      = regexp.MustCompile(`plural\s*=\s*(.*?);?\s*$`)
+)
+
+func parseMOPluralRule(header string) moPluralRule {
+	var rule moPluralRule
+	if m := reNPlurals.FindStringSubmatch(header); m != nil {
+		rule.n, _ = strconv.Atoi(m[1])
+	}
+	m := rePluralExpr.FindStringSubmatch(header)
+	if m == nil {
+		return rule
+	}
+	expr := strings.Join(strings.Fields(m[1]), "")
+	for _, p := range pluralTable {
+		if p.N == rule.n && strings.Join(strings.Fields(p.Expr), "") == expr {
+			p := p
+			rule.forms = &p
+			break
 		}
 	}
-	return "", ""
+	return rule
 }
 
-func checkInternet() bool {
-	conn, err := net.DialTimeout("tcp", "8.8.8.8:53", 2*time.Second)
-	if err != nil { return false }
-	conn.Close()
-	return true
+// single diz se a forma i vale para um só número (ex: n == 1).
+func (r moPluralRule) single(i int) bool {
+	if r.forms == nil {
+		return false
+	}
+	count := 0
+	for n := 0; n <= 1000 && count < 2; n++ {
+		if r.forms.index(n) == i {
+			count++
+		}
+	}
+	return count == 1
 }
 
-func detectDistro() string {
-	osData, _ := os.ReadFile("/etc/os-release")
-	osContent := string(osData)
-	re := regexp.MustCompile(`(?m)^ID=["']?([^"'\s]+)["']?`)
-	match := re.FindStringSubmatch(osContent)
-	if len(match) > 1 { return strings.ToLower(match[1]) }
-	return "unknown"
-}
+// writeMO grava o .mo no formato do GNU gettext (revisão 0): cabeçalho,
+// tabelas de originais e traduções, tabela hash e as strings, que terminam
+// em \x00. A tabela hash é a do msgfmt (hashpjw, tamanho primo, sondagem
+// dupla), para que o loader do gettext encontre cada mensagem sem busca
+// binária.
+func writeMO(w io.Writer, msgs []moMessage, order binary.ByteOrder) error {
+	n := uint32(len(msgs))
+	hashSize := nextPrime(n * 4 / 3)
+	if hashSize <= 2 {
+		hashSize = 3
+	}
+	origOffset := uint32(28)
+	transOffset := origOffset + 8*n
+	hashOffset := transOffset + 8*n
+	strOffset := hashOffset + 4*hashSize
 
-func checkDependencies() {
-	deps := map[string]string{
-		"xgettext": "gettext", "msginit":  "gettext", "msgfmt":   "gettext",
-		"gettext":  "gettext", "ngettext": "gettext", "trans":    "translate-shell",
+	table := []uint32{moMagic, 0, n, origOffset, transOffset, hashSize, hashOffset}
+	offset := strOffset
+	for _, m := range msgs {
+		table = append(table, uint32(len(m.key)), offset)
+		offset += uint32(len(m.key)) + 1
 	}
-	missingMap := make(map[string]bool)
-	hasMissing := false
-	for bin, pkg := range deps {
-		if _, err := exec.LookPath(bin); err != nil {
-			missingMap[pkg] = true
-			hasMissing = true
+	for _, m := range msgs {
+		table = append(table, uint32(len(m.value)), offset)
+		offset += uint32(len(m.value)) + 1
+	}
+	hash := make([]uint32, hashSize)
+	for j, m := range msgs {
+		h := hashPJW(m.key)
+		idx := h % hashSize
+		if hash[idx] != 0 {
+			incr := 1 + h%(hashSize-2)
+			for hash[idx] != 0 {
+				if idx >= hashSize-incr {
+					idx -= hashSize - incr
+				} else {
+					idx += incr
+				}
+			}
 		}
+		hash[idx] = uint32(j) + 1
 	}
-	if !hasMissing { return }
-	var missingPkgs []string
-	for pkg := range missingMap { missingPkgs = append(missingPkgs, pkg) }
-	pkgList := strings.Join(missingPkgs, " ")
-	muConsole.Lock()
-	fmt.Printf("\n%s %s\n", red(" [ERRO]"), white(T("Dependências ausentes: ")+pkgList))
-	distro := detectDistro()
-	installCmd := ""
-	switch distro {
-	case "chili", "chililinux", "arch": installCmd = "sudo pacman -S " + pkgList
-	case "void": installCmd = "sudo xbps-install -S " + pkgList
-	case "debian", "ubuntu": installCmd = "sudo apt install " + pkgList
-	case "fedora": installCmd = "sudo dnf install " + pkgList
+	table = append(table, hash...)
+
+	var buf bytes.Buffer
+	buf.Grow(int(offset))
+	binary.Write(&buf, order, table)
+	for _, m := range msgs {
+		buf.WriteString(m.key)
+		buf.WriteByte(0)
 	}
-	if installCmd != "" {
-		fmt.Printf("\n %s %s (%s)? (s/N): ", yellow(" →"), T("Deseja instalar automaticamente para"), cyan(distro))
-		muConsole.Unlock()
-		var response string
-		fmt.Scanln(&response)
-		if strings.ToLower(response) == "s" {
-			args := strings.Fields(installCmd)
-			cmd := exec.Command(args[0], args[1:]...)
-			cmd.Stdout, cmd.Stderr, cmd.Stdin = os.Stdout, os.Stderr, os.Stdin
-			if err := cmd.Run(); err == nil { return }
+	for _, m := range msgs {
+		buf.WriteString(m.value)
+		buf.WriteByte(0)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// hashPJW é o hash_string do gettext; só vale até o primeiro \x00, ou seja,
+// o msgid_plural não entra no hash.
+func hashPJW(s string) uint32 {
+	var h uint32
+	for i := 0; i < len(s) && s[i] != 0; i++ {
+		h = h<<4 + uint32(s[i])
+		if g := h & 0xf0000000; g != 0 {
+			h ^= g >> 24
+			h ^= g
 		}
-	} else { muConsole.Unlock() }
-	os.Exit(1)
+	}
+	return h
 }
 
-func loadCache() {
-	cacheData = make(map[string]map[string]CacheEntry)
-	file, err := os.ReadFile(cacheFile)
-	if err == nil { json.Unmarshal(file, &cacheData) }
+func nextPrime(n uint32) uint32 {
+	n |= 1
+	for !isPrime(n) {
+		n += 2
+	}
+	return n
 }
 
-func saveCache() {
-	mu.Lock()
-	defer mu.Unlock()
-	data, _ := json.MarshalIndent(cacheData, "", "  ")
-	os.WriteFile(cacheFile, data, 0644)
+func isPrime(n uint32) bool {
+	div := uint32(3)
+	for div*div < n && n%div != 0 {
+		div += 2
+	}
+	return n%div != 0
 }
 
-func copyFile(src, dst string) error {
-	s, _ := os.Open(src); defer s.Close()
-	d, _ := os.Create(dst); defer d.Close()
-	_, err := io.Copy(d, s)
-	return err
+// --- FORMAS DE PLURAL ---
+
+// pluralForms é a regra de plural de um idioma: Expr vai para o cabeçalho
+// Plural-Forms e index faz a mesma conta em Go, para escolher um número de
+// exemplo de cada forma na hora de traduzir.
+type pluralForms struct {
+	N     int
+	Expr  string
+	index func(n int) int
 }
 
-func hasActualContent(ext, baseName string) bool {
-	if selfFlag { return true }
-	isMan, _ := regexp.MatchString(`^\.[1-9]$`, ext)
-	if isMan { return true }
-	if ext == ".md" || ext == ".markdown" || ext == ".txt" || ext == ".json" || ext == ".yaml" || ext == ".yml" || ext == ".html" || ext == ".htm" { return true }
-	potFile := filepath.Join("pot", baseName+".pot")
-	if _, err := os.Stat(potFile); err == nil {
-		content, _ := os.ReadFile(potFile)
-		return strings.Contains(string(content), "msgid")
+func (p pluralForms) header() string {
+	return fmt.Sprintf("nplurals=%d; plural=%s;", p.N, p.Expr)
+}
+
+func pluralIf(b bool) int {
+	if b {
+		return 1
 	}
-	return true
+	return 0
 }
 
-func cleanupEmpty(ext, baseName string) {
-	potFile := filepath.Join("pot", baseName+".pot")
-	os.Remove(potFile)
+var (
+	pluralNone = pluralForms{1, "0", func(n int) int { return 0 }}
+	pluralOne  = pluralForms{2, "(n != 1)", func(n int) int { return pluralIf(n != 1) }}
+	pluralZero = pluralForms{2, "(n > 1)", func(n int) int { return pluralIf(n > 1) }}
+	pluralEast = pluralForms{3, "(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2)", func(n int) int {
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return 0
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20):
+			return 1
+		}
+		return 2
+	}}
+	pluralCzech = pluralForms{3, "(n==1) ? 0 : (n>=2 && n<=4) ? 1 : 2", func(n int) int {
+		switch {
+		case n == 1:
+			return 0
+		case n >= 2 && n <= 4:
+			return 1
+		}
+		return 2
+	}}
+)
+
+// pluralTable segue as regras do gettext (e do CLDR) para cada idioma de
+// supportedLanguages.
+var pluralTable = map[string]pluralForms{
+	"ar": {6, "(n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5)", func(n int) int {
+		switch {
+		case n == 0:
+			return 0
+		case n == 1:
+			return 1
+		case n == 2:
+			return 2
+		case n%100 >= 3 && n%100 <= 10:
+			return 3
+		case n%100 >= 11:
+			return 4
+		}
+		return 5
+	}},
+	"bg": pluralOne, "cs": pluralCzech, "da": pluralOne, "de": pluralOne,
+	"el": pluralOne, "en": pluralOne, "es": pluralOne, "et": pluralOne,
+	"fa": pluralZero, "fi": pluralOne, "fr": pluralZero, "he": pluralOne,
+	"hi": pluralOne, "hr": pluralEast, "hu": pluralOne, "it": pluralOne,
+	"is": {2, "(n%10 != 1 || n%100 == 11)", func(n int) int { return pluralIf(n%10 != 1 || n%100 == 11) }},
+	"ja": pluralNone, "ko": pluralNone, "nl": pluralOne, "no": pluralOne,
+	"pl": {3, "(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2)", func(n int) int {
+		switch {
+		case n == 1:
+			return 0
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20):
+			return 1
+		}
+		return 2
+	}},
+	"pt": pluralOne, "pt_PT": pluralOne, "pt_BR": pluralZero,
+	"ro": {3, "(n==1 ? 0 : (n==0 || (n%100 > 0 && n%100 < 20)) ? 1 : 2)", func(n int) int {
+		switch {
+		case n == 1:
+			return 0
+		case n == 0 || (n%100 > 0 && n%100 < 20):
+			return 1
+		}
+		return 2
+	}},
+	"ru": pluralEast, "sk": pluralCzech, "sv": pluralOne, "tr": pluralOne,
+	"uk": pluralEast, "zh": pluralNone, "zh_CN": pluralNone, "zh_TW": pluralNone,
 }
 
-func printWelcome(desc string) {
-	fmt.Printf("\n%s %s %s\n", cyan(">>"), white(_APP_), white(_VERSION_))
-	fmt.Printf("%s %s\n", yellow(T("[STEP 1]")), white(T("Ambiente preparado com sucesso.")))
-	fmt.Printf("    → %-15s: %s\n", T("Arquivo"), white(currentFile))
-	fmt.Printf("    → %-15s: %s\n", T("Tipo"), cyan(desc))
-	fmt.Printf("    → %-15s: %s\n", T("Motor"), green(engine))
-	fmt.Printf("    → %-15s: %s (%s)\n", T("Origem"), green(sourceLang), T("Auto-detect se auto"))
-	fmt.Printf("    → %-15s: %s\n", T("Jobs"), red(jobs))
-	fmt.Printf("    → %-15s: %s\n\n", T("Cache"), blue(cacheFile))
+// pluralFormsFor devolve a regra do idioma (pt_BR, depois pt); sem regra
+// conhecida, a do inglês.
+func pluralFormsFor(lang string) pluralForms {
+	if p, ok := pluralTable[lang]; ok {
+		return p
+	}
+	base, _, _ := strings.Cut(lang, "_")
+	if p, ok := pluralTable[base]; ok {
+		return p
+	}
+	return pluralOne
 }
 
-func showQuickStats(start time.Time) {
-	total := cacheHits + netCalls
-	pCache, pNet := 0.0, 0.0
-	if total > 0 {
-		pCache = (float64(cacheHits) / float64(total)) * 100
-		pNet = (float64(netCalls) / float64(total)) * 100
+// examples escolhe um número para cada forma: o menor a partir de 2, para
+// que o motor devolva a flexão de uma quantidade de verdade; 1 e 0 só
+// quando a forma não tem outro número.
+func (p pluralForms) examples() []int {
+	ex := make([]int, p.N)
+	for i := range ex {
+		ex[i] = -1
 	}
-	fmt.Printf("\n\n%s %s em %v | %s %d (%.2f%%) | %s %d (%.2f%%) | %s %d\n", green("✔"), white(T("Concluído")), time.Since(start).Round(time.Second), blue(T("Cache:")), cacheHits, pCache, yellow(T("Net:")), netCalls, pNet, white(T("Total:")), total)
+	for _, n := range append(seqInts(2, 1000), 1, 0) {
+		if i := p.index(n); i < p.N && ex[i] < 0 {
+			ex[i] = n
+		}
+	}
+	return ex
 }
 
-func showFinalSummary(start time.Time) {
-	fmt.Printf("%s\n %s\n", white(strings.Repeat("-", 60)), yellow(T("RESUMO EXECUTIVO FINAL:")))
-	fmt.Printf("    → %-15s: %v\n", T("Tempo Total"), time.Since(start).Round(time.Second))
-	fmt.Printf("    → %-15s: %d\n", T("Cache Hits"), cacheHits)
-	fmt.Printf("    → %-15s: %d\n", T("Chamadas Rede"), netCalls)
-	if atomic.LoadInt32(&failedCalls) > 0 {
-		fmt.Printf("    → %-15s: %s\n", T("Falhas"), red(atomic.LoadInt32(&failedCalls)))
+func seqInts(from, to int) []int {
+	s := make([]int, 0, to-from+1)
+	for n := from; n <= to; n++ {
+		s = append(s, n)
 	}
-	fmt.Printf("%s\n\n", white(strings.Repeat("-", 60)))
+	return s
 }
 
-func doCleanCache() {
-	limit := time.Now().AddDate(0, 0, -30)
-	count := 0
-	for l := range cacheData {
-		for id, e := range cacheData[l] {
-			if e.LastUsed.Before(limit) {
-				delete(cacheData[l], id)
-				count++
-			}
+var (
+	reNumberVerb = regexp.MustCompile(`%(?:\d+\$)?[-+ #0']*\d*(?:hh|h|ll|l|j|z|t)?[diu]`)
+	reDigits     = regexp.MustCompile(`\d+`)
+)
+
+// pluralSource é o texto mandado ao motor para uma forma de plural: o
+// msgid (n == 1) ou o msgid_plural, com o primeiro %d trocado pelo número
+// de exemplo; verb guarda o %d para recolocá-lo na tradução.
+type pluralSource struct {
+	text string
+	n    int
+	verb string
+}
+
+func pluralSources(e *poEntry, p pluralForms) []pluralSource {
+	var srcs []pluralSource
+	for _, n := range p.examples() {
+		text := e.Plural
+		if n == 1 {
+			text = e.ID
+		}
+		src := pluralSource{text: text, n: n}
+		if loc := reNumberVerb.FindStringIndex(text); loc != nil && n >= 0 {
+			src.verb = text[loc[0]:loc[1]]
+			src.text = text[:loc[0]] + strconv.Itoa(n) + text[loc[1]:]
+		}
+		srcs = append(srcs, src)
+	}
+	return srcs
+}
+
+// restore recoloca o %d no lugar do número de exemplo na tradução. Devolve
+// false se o motor escreveu o número por extenso ou noutro formato e ele
+// não foi achado.
+func (s pluralSource) restore(translated string) (string, bool) {
+	if s.verb == "" {
+		return translated, true
+	}
+	n := strconv.Itoa(s.n)
+	for _, loc := range reDigits.FindAllStringIndex(translated, -1) {
+		if translated[loc[0]:loc[1]] == n {
+			return translated[:loc[0]] + s.verb + translated[loc[1]:], true
 		}
 	}
-	fmt.Printf("%s %s %d %s\n", green("✔"), T("Removidos"), count, T("itens obsoletos do cache."))
+	return translated, false
 }
 
 func showVersion() { fmt.Printf("%s %s\n%s\n", cyan(_APP_), white(_VERSION_), white(_COPY_)) }
 
 func usage() {
 	fmt.Fprintf(os.Stderr, "\n%s %s\n%s\n\n", cyan(_APP_), white(_VERSION_), white(_COPY_))
-	fmt.Fprintf(os.Stderr, "%s: %s %s %s\n\n", yellow(T("Uso")), green(_APP_), yellow("-i"), green(T("<arquivo> [opções]")))
+	fmt.Fprintf(os.Stderr, "%s: %s %s %s\n", yellow(T("Uso")), green(_APP_), yellow("-i"), green(T("<arquivo> [opções]")))
+	fmt.Fprintf(os.Stderr, "     %s %s %s\n\n", green(_APP_), yellow("cache"), green(T("<subcomando> [opções]")))
 	fmt.Fprintf(os.Stderr, "%s:\n", yellow(T("Opções")))
 	defLangs := strings.Join(defaultLanguages, ",")
 	flags := []struct{ short, long, desc string }{
 		{"-i", "--inputfile", T("Arquivo fonte (.sh, .py, .md, .txt, .json, .yaml, .html, .pot, .[1-9])")},
 		{"-l", "--language", fmt.Sprintf(T("Idiomas (ex: pt_BR,en) ou 'all' (padrão: %s)"), defLangs)},
-		{"-e", "--engine", T("Motor: google, bing, yandex (padrão: google)")},
+		{"-e", "--engine", fmt.Sprintf(T("Motor: %s (padrão: google); vários = fallback em ordem"), strings.Join(engineNames(), ", "))},
+		{"", "--route", T("Motor preferido por idioma (ex: ru=yandex,zh_CN=bing)")},
+		{"", "--endpoint", T("URL do servidor do motor (libretranslate: http://localhost:5000)")},
+		{"", "--api-key", T("Chave de API do motor (ou LIBRETRANSLATE_API_KEY, DEEPL_API_KEY)")},
+		{"", "--formality", T("Formalidade do DeepL: default, more, less, prefer_more, prefer_less")},
+		{"", "--glossary", T("Glossário do DeepL: <id> ou <idioma>=<id> (exige -s)")},
+		{"", "--model", T("Modelo do motor llm/ollama (padrão: llama3.1)")},
+		{"", "--prompt-template", T("Arquivo com o template (text/template) do prompt do motor llm/ollama")},
+		{"", "--config", fmt.Sprintf(T("Arquivo de configuração (padrão: %s)"), configFile)},
 		{"-j", "--jobs", T("Traduções simultâneas (padrão: 8)")},
+		{"", "--batch-size", T("Máximo de segmentos por chamada ao motor; 1 desativa lotes (padrão: 40)")},
+		{"", "--batch-chars", T("Máximo de caracteres por lote (padrão: 4500)")},
+		{"", "--timeout", T("Tempo máximo por chamada ao motor; o processo e seus filhos são mortos (padrão: 60s)")},
+		{"", "--rate-limit", T("Requisições por segundo por motor (padrão: sem limite)")},
+		{"", "--chars-per-minute", T("Caracteres por minuto por motor (padrão: sem limite)")},
+		{"", "--retries", T("Tentativas por motor antes do fallback (padrão: 3)")},
+		{"", "--backoff", T("Espera inicial entre tentativas, dobrada a cada falha (padrão: 1s)")},
+		{"", "--backoff-max", T("Espera máxima entre tentativas (padrão: 30s)")},
+		{"", "--breaker-threshold", T("Falhas seguidas que pausam o motor; 0 desativa (padrão: 5)")},
+		{"", "--breaker-cooldown", T("Tempo de pausa do motor com o circuito aberto (padrão: 1m)")},
 		{"-s", "--source", T("Idioma de origem (ex: pt, en) (padrão: auto)")},
-		{"-f", "--force", T("Força nova tradução (ignora cache)")},
+		{"-f", "--force", T("Força nova tradução (ignora cache, exceto traduções humanas)")},
+		{"", "--force-human", T("Com --force, retraduz também o que foi revisado por pessoas")},
+		{"", "--cache-fallback", T("Sem tradução deste motor no cache, aceita a de outro motor ou de versões antigas")},
+		{"", "--resume", T("Pula os pares arquivo/idioma já concluídos numa execução interrompida")},
 		{"", "--self", T("Extração especializada para o próprio chili-tradutor-go")},
 		{"", "--self-test", T("Executa auto-teste de integridade")},
-		{"", "--clean-cache", T("Remove entradas de cache não usadas há 30 dias")},
+		{"", "--clean-cache", T("Aplica a política de expurgo agora (TTL padrão: 30 dias)")},
+		{"", "--cache-ttl", T("Ao salvar, remove entradas sem uso há mais que isso (ex: 90d, 720h)")},
+		{"", "--cache-max-entries", T("Ao salvar, mantém só as N entradas de uso mais recente")},
+		{"", "--cache-max-size", T("Ao salvar, limita o tamanho do cache (ex: 50M)")},
+		{"", "--cache-url", T("Servidor de cache da equipe (ex: http://cache:8787)")},
+		{"", "--cache-token", T("Token do servidor de cache (padrão: $CHILI_CACHE_TOKEN)")},
 		{"-q", "--quiet", T("Modo silencioso")},
 		{"-v", "--verbose", T("Mostrar detalhes")},
 		{"-V", "--version", T("Mostra a versão do programa")},
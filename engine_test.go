@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSplitBatch(t *testing.T) {
+	batch := []string{"Um", "Dois CHILI_REF_0_CHILI", "Três"}
+	joined := strings.ToUpper(joinBatch(batch))
+	for _, c := range []struct {
+		name string
+		out  string
+		want string
+	}{
+		{"ida e volta", joined, "UM|DOIS CHILI_REF_0_CHILI|TRÊS"},
+		{"separador sem quebra", "UM CHILI_SEP_1_CHILI DOIS CHILI_SEP_2_CHILI TRÊS", "UM|DOIS|TRÊS"},
+		{"faltando", "UM DOIS", ""},
+		{"fora de ordem", "UM\nCHILI_SEP_2_CHILI\nDOIS\nCHILI_SEP_1_CHILI\nTRÊS", ""},
+	} {
+		parts, err := splitBatch(c.out, len(batch))
+		if c.want == "" {
+			if !errors.Is(err, errBatchSplit) {
+				t.Errorf("%s: err = %v, want errBatchSplit", c.name, err)
+			}
+			continue
+		}
+		if err != nil || strings.Join(parts, "|") != c.want {
+			t.Errorf("%s: got %q, %v; want %q", c.name, parts, err, c.want)
+		}
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	c := &circuitBreaker{threshold: 2, cooldown: 20 * time.Millisecond}
+	c.failure()
+	c.failure()
+	c.failure() // chamada que já estava em andamento
+	if c.allow() || c.trips != 1 {
+		t.Fatalf("after threshold: allow or trips = %d, want closed and 1", c.trips)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if !c.allow() {
+		t.Fatal("half-open: probe not allowed")
+	}
+	if c.allow() {
+		t.Fatal("half-open: second call allowed during probe")
+	}
+	c.failure()
+	if c.allow() || c.trips != 2 {
+		t.Fatalf("failed probe: trips = %d, want reopened and 2", c.trips)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if !c.allow() {
+		t.Fatal("second probe not allowed")
+	}
+	c.success()
+	if !c.allow() || !c.allow() {
+		t.Fatal("breaker did not close after a successful probe")
+	}
+}
+
+func TestLibreTranslateEngine(t *testing.T) {
+	var gotTarget string
+	var langCalls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/languages", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&langCalls, 1) == 1 {
+			http.Error(w, "indisponível", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `[{"code":"en"},{"code":"pt-BR"},{"code":"zh-Hans"}]`)
+	})
+	mux.HandleFunc("/translate", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Q      []string `json:"q"`
+			Target string   `json:"target"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		gotTarget = req.Target
+		for i := range req.Q {
+			req.Q[i] = strings.ToUpper(req.Q[i])
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"translatedText": req.Q})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	eng := newLibreTranslateEngine(srv.URL, "")
+	// A primeira consulta a /languages falha: usa o código padrão e tenta de novo.
+	if _, err := eng.Translate(context.Background(), "ok", "auto", "pt_BR"); err != nil || gotTarget != "pt" {
+		t.Fatalf("first call: %v, target %q; want target pt", err, gotTarget)
+	}
+	res, err := eng.Translate(context.Background(), "ok CHILI_REF_0_CHILI", "auto", "pt_BR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != "OK CHILI_REF_0_CHILI" || gotTarget != "pt-BR" {
+		t.Fatalf("got %q (target %q), want %q (target pt-BR)", res, gotTarget, "OK CHILI_REF_0_CHILI")
+	}
+}
+
+func TestEngineLocal(t *testing.T) {
+	for _, c := range []struct {
+		engine Engine
+		want   bool
+	}{
+		{newLibreTranslateEngine("http://localhost:5000", ""), true},
+		{newLibreTranslateEngine("http://127.0.0.1:5000", ""), true},
+		{newLibreTranslateEngine("http://192.168.0.10:5000", ""), true},
+		{newLibreTranslateEngine("http://tradutor.local", ""), true},
+		{newLibreTranslateEngine("https://libretranslate.com", ""), false},
+		{&llmEngine{endpoint: "http://localhost:11434"}, true},
+		{&llmEngine{endpoint: "https://api.openai.com/v1"}, false},
+		{&transShellEngine{backend: "google"}, false},
+	} {
+		if got := isLocalEngine(c.engine); got != c.want {
+			t.Errorf("%s %T: Local = %v, want %v", c.engine.Name(), c.engine, got, c.want)
+		}
+	}
+}
+
+func TestCommandEngine(t *testing.T) {
+	eng, err := newCommandEngine("upper", CommandConfig{
+		Command: "sh",
+		Args:    []string{"-c", `printf '{"out":{"%s":"%s"}}' "$1" "$(tr a-z A-Z)"`, "sh", "{tgt}"},
+		Output:  "json:out.de",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := eng.Translate(context.Background(), "hallo", "auto", "de")
+	if err != nil || res != "HALLO" {
+		t.Fatalf("got %q, %v; want HALLO", res, err)
+	}
+	if _, ok := Engine(eng).(batchEngine); ok {
+		t.Error("command engine offers batches without a native batch API")
+	}
+
+	slow, err := newCommandEngine("slow", CommandConfig{Command: "sleep", Args: []string{"5"}, Timeout: "100ms"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := slow.Translate(context.Background(), "x", "auto", "de"); err == nil || time.Since(start) > 2*time.Second {
+		t.Fatalf("timeout not honoured: %v after %s", err, time.Since(start))
+	}
+}
+
+func TestLLMEngine(t *testing.T) {
+	var gotPrompt string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Messages) > 0 {
+			gotPrompt = req.Messages[0].Content
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"content":" Olá CHILI_REF_0_CHILI \n"}}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	eng, err := newLLMEngine("llm", srv.URL, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := eng.Translate(context.Background(), "Hello CHILI_REF_0_CHILI", "auto", "pt_BR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != "Olá CHILI_REF_0_CHILI" {
+		t.Errorf("got %q, want %q", res, "Olá CHILI_REF_0_CHILI")
+	}
+	if !strings.Contains(gotPrompt, "CHILI_REF_0_CHILI") || !strings.Contains(gotPrompt, "pt_BR") {
+		t.Errorf("prompt lacks placeholder or target language: %q", gotPrompt)
+	}
+	if key := engineCacheKey(eng); key != "llm:llama3.1" {
+		t.Errorf("cache key = %q, want llm:llama3.1", key)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestCompileMO(t *testing.T) {
+	po, err := parsePO(strings.NewReader(`msgid ""
+msgstr ""
+"Content-Type: text/plain; charset=UTF-8\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+msgid "Open"
+msgstr "Abrir"
+
+msgctxt "menu"
+msgid "Open"
+msgstr "Abrir menu"
+
+#, c-format
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d arquivo"
+msgstr[1] "%d arquivos"
+
+#, fuzzy
+msgid "Draft"
+msgstr "Rascunho"
+
+msgid "Empty"
+msgstr ""
+
+#, c-format
+msgid "Hello %s"
+msgstr "Olá"
+
+#, c-format
+msgid "%s: %d"
+msgstr "%d: %s"
+
+#, c-format
+msgid "%s of %s"
+msgstr "%2$s de %1$s"
+
+#, c-format
+msgid "%s: %d item"
+msgid_plural "%s: %d items"
+msgstr[0] "%s: um item"
+msgstr[1] "%s: itens"
+
+#, c-format
+msgid "%d row"
+msgid_plural "%d rows"
+msgstr[0] "uma linha"
+msgstr[1] "%d linhas"
+
+msgid "Line\n"
+msgstr "Linha"
+
+#~ msgid "Old"
+#~ msgstr "Velho"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs, errs := compileMO(po, false)
+	if len(msgs) != 6 || len(errs) != 4 {
+		t.Fatalf("%d messages, errors %v; want 6 and 4 errors", len(msgs), errs)
+	}
+	want := map[string]string{
+		"Open":         "Abrir",
+		"menu\x04Open": "Abrir menu",
+		"%d file":      "%d arquivo\x00%d arquivos",
+		"%s of %s":     "%2$s de %1$s",
+		"%d row":       "uma linha\x00%d linhas",
+	}
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		var buf bytes.Buffer
+		if err := writeMO(&buf, msgs, order); err != nil {
+			t.Fatal(err)
+		}
+		data := buf.Bytes()
+		if order.Uint32(data) != moMagic || order.Uint32(data[8:]) != 6 || order.Uint32(data[20:]) != 11 {
+			t.Fatalf("%s: header % x", order, data[:28])
+		}
+		for key, value := range want {
+			if got, ok := moLookup(data, key); !ok || got != value {
+				t.Errorf("%s %q = %q, %v; want %q", order, key, got, ok, value)
+			}
+		}
+		if header, _ := moLookup(data, ""); !strings.Contains(header, "nplurals=2") {
+			t.Errorf("%s: header entry %q", order, header)
+		}
+		for _, key := range []string{"Draft", "Empty", "Old", "Hello %s", "%s: %d", "%s: %d item"} {
+			if _, ok := moLookup(data, key); ok {
+				t.Errorf("%s: %q should not be in the .mo", order, key)
+			}
+		}
+	}
+}
+
+func TestMOHash(t *testing.T) {
+	if got := hashPJW("Open"); got != 0x566be {
+		t.Errorf("hashPJW(Open) = %#x, want 0x566be", got)
+	}
+	for n, want := range map[uint32]uint32{4: 5, 9: 11} {
+		if got := nextPrime(n); got != want {
+			t.Errorf("nextPrime(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+// moLookup procura uma mensagem num .mo como o loader do gettext (dcigettext):
+// pela tabela hash, ou por busca binária se o arquivo não tiver tabela.
+func moLookup(data []byte, key string) (string, bool) {
+	if len(data) < 28 {
+		return "", false
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if order.Uint32(data) != moMagic {
+		order = binary.BigEndian
+		if order.Uint32(data) != moMagic {
+			return "", false
+		}
+	}
+	word := func(off uint32) uint32 {
+		if int(off)+4 > len(data) {
+			return 0
+		}
+		return order.Uint32(data[off:])
+	}
+	str := func(table, i uint32) string {
+		length, off := word(table+8*i), word(table+8*i+4)
+		if int(off+length) > len(data) {
+			return ""
+		}
+		return string(data[off : off+length])
+	}
+	n, origTab, transTab := word(8), word(12), word(16)
+	hashSize, hashTab := word(20), word(24)
+	sameKey := func(i uint32) bool {
+		orig := str(origTab, i)
+		if k, _, ok := strings.Cut(orig, "\x00"); ok {
+			orig = k
+		}
+		return orig == key
+	}
+	if hashSize > 2 {
+		h := hashPJW(key)
+		idx, incr := h%hashSize, 1+h%(hashSize-2)
+		for {
+			nstr := word(hashTab + 4*idx)
+			if nstr == 0 || nstr > n {
+				return "", false
+			}
+			if sameKey(nstr - 1) {
+				return str(transTab, nstr-1), true
+			}
+			if idx >= hashSize-incr {
+				idx -= hashSize - incr
+			} else {
+				idx += incr
+			}
+		}
+	}
+	i := sort.Search(int(n), func(i int) bool { return str(origTab, uint32(i)) >= key })
+	if i < int(n) && sameKey(uint32(i)) {
+		return str(transTab, uint32(i)), true
+	}
+	return "", false
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPluralTableCoversLanguages(t *testing.T) {
+	for _, lang := range supportedLanguages {
+		p, ok := pluralTable[lang]
+		if !ok {
+			t.Errorf("%s: no plural rule", lang)
+			continue
+		}
+		for i, n := range p.examples() {
+			if n < 0 || p.index(n) != i {
+				t.Errorf("%s: form %d has no example (got %d)", lang, i, n)
+			}
+		}
+	}
+}
+
+func TestPluralFormsFor(t *testing.T) {
+	for lang, n := range map[string]int{"pt_BR": 2, "ja": 1, "ar": 6, "ru": 3} {
+		if got := pluralFormsFor(lang).N; got != n {
+			t.Errorf("%s: nplurals = %d, want %d", lang, got, n)
+		}
+	}
+	want := "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);"
+	if got := pluralFormsFor("ru").header(); got != want {
+		t.Errorf("ru header = %q, want %q", got, want)
+	}
+}
+
+func TestPluralSources(t *testing.T) {
+	ru := pluralFormsFor("ru")
+	srcs := pluralSources(&poEntry{ID: "One file", Plural: "%d files"}, ru)
+	if len(srcs) != 3 || srcs[0].text != "21 files" || srcs[1].text != "2 files" || srcs[2].text != "5 files" {
+		t.Fatalf("ru sources = %+v", srcs)
+	}
+	for _, c := range []struct {
+		form       int
+		translated string
+		want       string
+		ok         bool
+	}{
+		{0, "21 файл", "%d файл", true},
+		{0, "121 файл, не 21", "121 файл, не %d", true},
+		{1, "два файла", "", false},
+	} {
+		got, ok := srcs[c.form].restore(c.translated)
+		if ok != c.ok || (ok && got != c.want) {
+			t.Errorf("restore(%q) = %q, %v; want %q, %v", c.translated, got, ok, c.want, c.ok)
+		}
+	}
+	if pl := pluralSources(&poEntry{ID: "One file", Plural: "%d files"}, pluralFormsFor("pl")); pl[0].text != "One file" {
+		t.Errorf("pl sources = %+v, want the singular msgid for form 0", pl)
+	}
+}
+
+func TestMergePO(t *testing.T) {
+	old, err := parsePO(strings.NewReader(`# revisado
+#: a.sh:1
+msgid "Save file"
+msgstr "Salvar arquivo"
+
+#: a.sh:2
+msgid "Open the file"
+msgstr "Abrir o arquivo"
+
+#: a.sh:3
+msgid "Gone away"
+msgstr "Foi embora"
+
+msgid "%d item"
+msgid_plural "%d items"
+msgstr[0] "%d элемент"
+msgstr[1] "%d элемента"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	po, err := parsePO(strings.NewReader(`#: b.sh:7
+msgid "Save file"
+msgstr ""
+
+#: b.sh:8
+msgid "Open the files"
+msgstr ""
+
+msgid "Brand new"
+msgstr ""
+
+msgid "%d item"
+msgid_plural "%d items"
+msgstr[0] ""
+msgstr[1] ""
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats := mergePO(po, old, 3); stats != (poMergeStats{Kept: 1, Fuzzy: 1, Obsolete: 1}) {
+		t.Errorf("stats = %+v", stats)
+	}
+	e := po.Entries
+	if len(e) != 5 {
+		t.Fatalf("%d entries, want 5", len(e))
+	}
+	if e[0].Str[0] != "Salvar arquivo" || strings.Join(e[0].Comments, "|") != "# revisado|#: b.sh:7" {
+		t.Errorf("kept entry = %+v", e[0])
+	}
+	if e[1].Str[0] != "Abrir o arquivo" || !e[1].hasFlag("fuzzy") || !containsString(e[1].Comments, `#| msgid "Open the file"`) {
+		t.Errorf("fuzzy entry = %+v", e[1])
+	}
+	if e[2].translated() || e[3].translated() {
+		t.Errorf("invalid translation reused: %+v %+v", e[2], e[3])
+	}
+	if !e[4].Obsolete || e[4].ID != "Gone away" {
+		t.Errorf("obsolete entry = %+v", e[4])
+	}
+}
+
+func TestMarkMachineTranslation(t *testing.T) {
+	e := &poEntry{Comments: []string{"# chili-tradutor-go: bing, 2020-01-01, fresh", "#: a.sh:1"}, Flags: []string{"c-format"}}
+	markMachineTranslation(e, []segmentOrigin{{Engine: "google", Cached: true}, {Engine: "google"}})
+	if len(e.Comments) != 2 || !strings.HasSuffix(e.Comments[0], ", cache+fresh") || !strings.HasPrefix(e.Comments[0], provenancePrefix+"google, ") {
+		t.Errorf("comments = %q", e.Comments)
+	}
+	if !e.hasFlag("fuzzy") || !e.hasFlag("c-format") {
+		t.Errorf("flags = %q", e.Flags)
+	}
+	h := &poEntry{}
+	markMachineTranslation(h, []segmentOrigin{{Engine: humanEngine, Cached: true}})
+	if h.hasFlag("fuzzy") || len(h.Comments) != 1 {
+		t.Errorf("human translation = %+v, want a comment and no fuzzy flag", h)
+	}
+}
+
+func TestSegmentsTranslated(t *testing.T) {
+	for _, c := range []struct {
+		texts   []string
+		origins []segmentOrigin
+		want    bool
+	}{
+		{[]string{"Olá", "  "}, []segmentOrigin{{Engine: "google"}, {}}, true},
+		{[]string{"Olá"}, []segmentOrigin{{}}, false},
+	} {
+		if got := segmentsTranslated(c.texts, c.origins); got != c.want {
+			t.Errorf("segmentsTranslated(%q, %v) = %v, want %v", c.texts, c.origins, got, c.want)
+		}
+	}
+}
+
+func TestParsePO(t *testing.T) {
+	src := `msgid ""
+msgstr ""
+"Language: pt_BR\n"
+
+#, fuzzy, c-format
+msgid "Diga \"oi\"\n"
+"em duas linhas"
+msgstr "Say \"hi\"\n"
+"in two lines"
+
+msgctxt "menu"
+msgid "Open"
+msgid_plural "Opens"
+msgstr[0] "Abrir"
+msgstr[1] "Abrem"
+
+#~ msgid "Velho"
+#~ msgstr "Old"
+`
+	po, err := parsePO(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if po.HeaderValue("Language") != "pt_BR" || len(po.Entries) != 3 {
+		t.Fatalf("header %v, %d entries", po.Header, len(po.Entries))
+	}
+	if e := po.Entries[0]; e.ID != "Diga \"oi\"\nem duas linhas" || e.Str[0] != "Say \"hi\"\nin two lines" || !e.hasFlag("fuzzy") {
+		t.Errorf("entry 0 = %q %q", e.ID, e.Str)
+	}
+	if e := po.Entries[1]; e.Context != "menu" || e.Plural != "Opens" || len(e.Str) != 2 || e.Str[1] != "Abrem" {
+		t.Errorf("entry 1 = %+v", e)
+	}
+	if e := po.Entries[2]; !e.Obsolete || e.ID != "Velho" {
+		t.Errorf("entry 2 = %+v", e)
+	}
+	if _, err := parsePO(strings.NewReader(`msgid "\u00e9"`)); err == nil {
+		t.Error(`\u escape accepted`)
+	}
+}
+
+func TestPORoundTrip(t *testing.T) {
+	for _, c := range []struct {
+		name, src, want string
+	}{
+		{"completo", `# Comentário do tradutor
+msgid ""
+msgstr ""
+"Project-Id-Version: chili\n"
+"Language: pt_BR\n"
+
+#. TRANSLATORS: comentário extraído
+#: main.go:10 main.go:20
+#, fuzzy, c-format
+#| msgid "Old %s"
+msgid "New %s"
+msgstr "Novo %s"
+
+#: main.go:30
+msgid ""
+"Uma linha comprida o bastante para ser quebrada pelo xgettext em mais de "
+"uma linha do arquivo\n"
+"e com quebra\tde linha"
+msgstr ""
+
+msgctxt "menu"
+msgid "File"
+msgid_plural "Files"
+msgstr[0] "Arquivo"
+msgstr[1] "Arquivos"
+
+#~ msgid "Velho"
+#~ msgstr "Old"
+`, ""},
+		// Escapes do C, "#," antes das referências e comentários no fim.
+		{"escapes", `# tradutor
+#, fuzzy
+#: a.c:1
+msgid "It\'s \x41\101\?"
+msgstr "É"
+
+# sobrou
+`, `# tradutor
+#, fuzzy
+#: a.c:1
+msgid "It's AA?"
+msgstr "É"
+
+# sobrou
+`},
+	} {
+		po, err := parsePO(strings.NewReader(c.src))
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		var out bytes.Buffer
+		po.WriteTo(&out)
+		want := c.want
+		if want == "" {
+			want = c.src
+		}
+		if out.String() != want {
+			t.Errorf("%s: got\n%s\nwant\n%s", c.name, out.String(), want)
+		}
+	}
+}
+
+func TestPOWrapsLongStrings(t *testing.T) {
+	po, err := parsePO(strings.NewReader("msgid \"\"\nmsgstr \"\"\n\"Language: pt_BR\\n\"\n\nmsgid \"Long\"\nmsgstr \"\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	po.Entries[0].Str = []string{strings.Repeat("palavra ", 20) + "\"fim\"\n"}
+	var out bytes.Buffer
+	po.WriteTo(&out)
+	for _, line := range strings.Split(out.String(), "\n") {
+		if len(line) > 79 {
+			t.Errorf("line longer than 79 columns: %s", line)
+		}
+	}
+	again, err := parsePO(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Entries[0].Str[0] != po.Entries[0].Str[0] || again.HeaderValue("Language") != "pt_BR" {
+		t.Errorf("reparsed %q, want %q", again.Entries[0].Str, po.Entries[0].Str)
+	}
+}
+
+func TestHumanCacheKeys(t *testing.T) {
+	// O import-po grava com origem auto; uma execução com -s en também acha.
+	saved := sourceLang
+	defer func() { sourceLang = saved }()
+	sourceLang = "en"
+	keys := humanCacheKeys("Open")
+	if !containsString(keys, "human|auto|Open") || !containsString(keys, "human|en|Open") {
+		t.Errorf("keys = %v", keys)
+	}
+}